@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"webhooker/internal/logging"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+)
+
+// authorize requires bearer token with scope, principal is passed to services in context
+func (h *Handlers) authorize(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
+	if h.auth == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		// payment provider verified by client certificate doesn't need token
+		if identity, ok := ClientIdentity(r.Context()); ok && scope == models.ScopeIngest {
+			principal := &models.Principal{Name: identity, Scopes: []models.Scope{models.ScopeIngest}}
+			next(w, r.WithContext(withPrincipal(r.Context(), principal)))
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "authorization required", http.StatusUnauthorized)
+			return
+		}
+		principal, err := h.auth.Authenticate(token)
+		if err != nil {
+			slog.InfoContext(r.Context(), "failed to authenticate request", logging.Err(err))
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if !principal.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(scope)+`"`)
+			http.Error(w, "insufficient scope", http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(withPrincipal(r.Context(), principal)))
+	}
+}
+
+// withPrincipal passes principal to services, client and end user are added to logs of request
+func withPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	var attrs []slog.Attr
+	switch {
+	case !principal.IsUser():
+		attrs = append(attrs, slog.String(logging.KeyClientID, principal.Name))
+	// name of end user is subject of its token, it shouldn't be logged without redaction
+	case principal.Name != principal.UserID:
+		attrs = append(attrs, slog.String(logging.KeySubject, principal.Name))
+	}
+	if principal.IsUser() {
+		attrs = append(attrs, slog.String(logging.KeyUserID, principal.UserID))
+	}
+	return services.WithPrincipal(logging.With(ctx, attrs...), principal)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// allowOrigin allows cross-origin request only from configured origins
+func (h *Handlers) allowOrigin(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	w.Header().Add("Vary", "Origin")
+	if origin != "" && slices.Contains(h.corsOrigins, origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
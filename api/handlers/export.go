@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"webhooker/internal/logging"
+	"webhooker/internal/services/models"
+)
+
+const (
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// rows are flushed in batches, not on every write
+	exportFlushRows = 100
+)
+
+var csvOrderHeader = []string{"order_id", "user_id", "order_status", "is_final", "created_at", "updated_at", "version"}
+
+var csvEventHeader = []string{"event_id", "event_status", "event_is_final", "event_is_system", "event_created_at", "event_updated_at", "event_received_at"}
+
+type ExportOrderResp struct {
+	Order  OrderResp         `json:"order"`
+	Events []ExportEventResp `json:"events,omitempty"`
+	// cursor to resume export after this order
+	Cursor string `json:"cursor"`
+}
+
+type ExportEventResp struct {
+	EventID    string  `json:"event_id"`
+	Status     string  `json:"order_status"`
+	IsFinal    bool    `json:"is_final"`
+	IsSystem   bool    `json:"is_system"`
+	CreateAt   string  `json:"created_at"`
+	UpdateAt   string  `json:"updated_at"`
+	ReceivedAt *string `json:"received_at"`
+}
+
+func eventToExportEventResp(e *models.Event) ExportEventResp {
+	resp := ExportEventResp{
+		EventID:  e.EventID,
+		Status:   e.OrderStatus,
+		IsFinal:  e.IsFinal,
+		IsSystem: e.IsSystem,
+		CreateAt: e.CreateAt.Format(timeLayout),
+		UpdateAt: e.UpdateAt.Format(timeLayout),
+	}
+	if !e.ReceivedAt.IsZero() {
+		receivedAt := e.ReceivedAt.Format(timeLayout)
+		resp.ReceivedAt = &receivedAt
+	}
+	return resp
+}
+
+// exportWriter writes one order per call, rows are buffered till flush
+type exportWriter interface {
+	write(order *models.Order, events []*models.Event, cursor string) error
+	flush() error
+}
+
+// ExportOrders streams all orders matching filter as csv or ndjson,
+// export can be resumed with cursor of the last received order
+func (h *Handlers) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var withEvents bool
+	if eventsStr := r.URL.Query().Get("events"); eventsStr != "" {
+		withEvents, err = strconv.ParseBool(eventsStr)
+		if err != nil {
+			http.Error(w, "invalid events", http.StatusBadRequest)
+			return
+		}
+	}
+
+	contentType, ok := negotiateExport(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "supported formats: "+contentTypeCSV+", "+contentTypeNDJSON, http.StatusNotAcceptable)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return
+	}
+
+	var out exportWriter
+	var filename string
+	if contentType == contentTypeCSV {
+		out = newCSVExport(w, withEvents)
+		filename = "orders.csv"
+	} else {
+		out = &ndjsonExport{enc: json.NewEncoder(w)}
+		filename = "orders.ndjson"
+	}
+
+	// headers are sent with the first row, errors before it get proper status
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	rows := 0
+	err = h.order.ExportOrders(r.Context(), filter, r.URL.Query().Get("cursor"), withEvents, func(order *models.Order, events []*models.Event, cursor string) error {
+		if !started {
+			start()
+		}
+		if err := out.write(order, events, cursor); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := out.flush(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			handleOrdersError(w, r, err)
+			return
+		}
+		// status is already sent, client resumes export with cursor of the last row
+		slog.ErrorContext(r.Context(), "export interrupted", slog.Int("orders", rows), logging.Err(err))
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if err := out.flush(); err != nil {
+		slog.ErrorContext(r.Context(), "failed to flush export", logging.Err(err))
+		return
+	}
+	flusher.Flush()
+}
+
+// negotiateExport picks export format from Accept header, ndjson is default
+func negotiateExport(accept string) (string, bool) {
+	if accept == "" {
+		return contentTypeNDJSON, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case contentTypeCSV:
+			return contentTypeCSV, true
+		case contentTypeNDJSON, "application/*", "*/*":
+			return contentTypeNDJSON, true
+		}
+	}
+	return "", false
+}
+
+type ndjsonExport struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExport) write(order *models.Order, events []*models.Event, cursor string) error {
+	resp := ExportOrderResp{
+		Order:  OrderToOrderResp(order),
+		Cursor: cursor,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, eventToExportEventResp(event))
+	}
+	// Encode writes value with trailing newline
+	return e.enc.Encode(resp)
+}
+
+func (e *ndjsonExport) flush() error {
+	return nil
+}
+
+// csvExport writes one row per event, order without events takes one row with empty event columns
+type csvExport struct {
+	w          *csv.Writer
+	withEvents bool
+	header     bool
+}
+
+func newCSVExport(w io.Writer, withEvents bool) *csvExport {
+	return &csvExport{
+		w:          csv.NewWriter(w),
+		withEvents: withEvents,
+	}
+}
+
+func (e *csvExport) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	header := append([]string{}, csvOrderHeader...)
+	if e.withEvents {
+		header = append(header, csvEventHeader...)
+	}
+	return e.w.Write(append(header, "cursor"))
+}
+
+func (e *csvExport) write(order *models.Order, events []*models.Event, cursor string) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	resp := OrderToOrderResp(order)
+	orderRow := []string{
+		resp.OrderID,
+		resp.UserID,
+		resp.Status,
+		strconv.FormatBool(resp.IsFinal),
+		resp.CreateAt,
+		resp.UpdateAt,
+		strconv.Itoa(resp.Version),
+	}
+	if !e.withEvents {
+		return e.w.Write(append(orderRow, cursor))
+	}
+	if len(events) == 0 {
+		return e.w.Write(append(append(orderRow, make([]string, len(csvEventHeader))...), cursor))
+	}
+	for _, event := range events {
+		eventResp := eventToExportEventResp(event)
+		receivedAt := ""
+		if eventResp.ReceivedAt != nil {
+			receivedAt = *eventResp.ReceivedAt
+		}
+		row := append(append([]string{}, orderRow...),
+			eventResp.EventID,
+			eventResp.Status,
+			strconv.FormatBool(eventResp.IsFinal),
+			strconv.FormatBool(eventResp.IsSystem),
+			eventResp.CreateAt,
+			eventResp.UpdateAt,
+			receivedAt,
+			cursor,
+		)
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvExport) flush() error {
+	// empty export still has header
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
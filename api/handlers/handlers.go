@@ -1,31 +1,156 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
+	"webhooker/internal/auth"
+	"webhooker/internal/health"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
+	"webhooker/internal/ratelimit"
 	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
 	timeLayout = time.RFC3339
+
+	// nginx status for request canceled by client, client doesn't receive it
+	statusClientClosedRequest = 499
 )
 
 type Handlers struct {
 	stream *services.WebhookService
 	order  *services.OrderService
+	stats  *services.StatsService
+	// webhookAuth requires client certificate for webhook route
+	webhookAuth ClientAuth
+	// auth is nil if auth is disabled
+	auth        *auth.Authenticator
+	corsOrigins []string
+	// tokens is nil if stream tokens aren't supported
+	tokens *services.StreamTokenService
+	limits Limits
+	// limiters of routes, they are created with routes
+	limiters map[string]*ratelimit.Limiter
+	streams  *ratelimit.Concurrency
+	health   *health.Checker
+	// draining is closed on shutdown
+	draining  chan struct{}
+	drainOnce sync.Once
+	// routes are patterns registered by GetHandlers, all of them are in openapi spec
+	routes []string
+}
+
+// Options of handlers, zero value disables auth and cross-origin requests
+type Options struct {
+	WebhookAuth ClientAuth
+	Auth        *auth.Authenticator
+	// CORSOrigins are allowed to read events stream from browser
+	CORSOrigins []string
+	// StreamTokens allows to open events stream by signed token in query
+	StreamTokens *services.StreamTokenService
+	Limits       Limits
+	// Health checks dependencies for readiness, without it service is always ready
+	Health *health.Checker
 }
 
-func NewHandler(stream *services.WebhookService, order *services.OrderService) *Handlers {
+func NewHandler(stream *services.WebhookService, order *services.OrderService, stats *services.StatsService, opts Options) *Handlers {
+	if opts.Health == nil {
+		opts.Health = health.NewChecker(0)
+	}
 	return &Handlers{
-		stream: stream,
-		order:  order,
+		stream:      stream,
+		order:       order,
+		stats:       stats,
+		webhookAuth: opts.WebhookAuth,
+		auth:        opts.Auth,
+		corsOrigins: opts.CORSOrigins,
+		tokens:      opts.StreamTokens,
+		limits:      opts.Limits,
+		limiters:    make(map[string]*ratelimit.Limiter),
+		streams:     ratelimit.NewConcurrency(opts.Limits.StreamsPerUser, opts.Limits.Streams),
+		health:      opts.Health,
+		draining:    make(chan struct{}),
 	}
 }
 
+// middleware checks request before handler, e.g. auth and rate limits of route
+type middleware func(next http.HandlerFunc) http.HandlerFunc
+
 func (h *Handlers) GetHandlers() *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /webhooks/payments/orders", h.ReceiveWebhook)
-	mux.HandleFunc("GET /orders", h.GetOrders)
-	mux.HandleFunc("GET /orders/{order_id}/events", h.StreamEvents)
+	h.routes = nil
+	route := func(pattern string, handler http.HandlerFunc) {
+		h.routes = append(h.routes, pattern)
+		mux.HandleFunc(pattern, handler)
+	}
+	// handle traces and logs request, span is named by pattern.
+	// Request is validated by openapi spec after auth, so unauthorized client gets 401 for any request
+	handle := func(pattern string, guard middleware, handler http.HandlerFunc) {
+		route(pattern, h.traced(pattern, h.logRequest(pattern, guard(h.validate(pattern, handler)))))
+	}
+	webhook := func(next http.HandlerFunc) http.HandlerFunc {
+		return h.acceptWebhooks(h.requireClientCert(h.authorize(models.ScopeIngest, h.rateLimit("webhook", h.limits.Webhook, next))))
+	}
+	handle(webhookRoute, webhook, h.ReceiveWebhook)
+	handle("GET /orders", h.read("orders"), h.GetOrders)
+	handle("GET /orders/{order_id}", h.read("timeline"), h.GetOrderTimeline)
+	stream := func(next http.HandlerFunc) http.HandlerFunc {
+		limited := h.rateLimit("events", h.limits.Read, h.limitStreams(next))
+		if h.tokens != nil {
+			return h.authorizeStream(limited)
+		}
+		return h.authorize(models.ScopeRead, limited)
+	}
+	handle("GET /orders/{order_id}/events", stream, h.StreamEvents)
+	if h.tokens != nil {
+		handle("POST /stream-tokens", h.read("stream-tokens"), h.IssueStreamToken)
+		handle("DELETE /stream-tokens/{token_id}", h.read("stream-tokens"), h.RevokeStreamToken)
+	}
+	handle("GET /stats/orders", h.read("stats"), h.GetOrderStats)
+	handle("GET /export/orders", h.read("export"), h.ExportOrders)
+	handle("GET /admin/usage", h.scope(models.ScopeAdmin), h.GetUsage)
+	// probes of orchestrator don't have tokens, probes and scrapes aren't traced
+	route("GET /healthz", h.Healthz)
+	route("GET /readyz", h.Readyz)
+	handle("GET /status", h.scope(models.ScopeAdmin), h.Status)
+	// metrics are totals and streamed orders bucketed by count of subscribers, without order ids
+	route("GET /metrics", h.authorize(models.ScopeAdmin, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}).ServeHTTP))
+	route("GET /openapi.json", h.OpenAPI)
 	return mux
 }
+
+// read is route with read scope and read rate limit
+func (h *Handlers) read(route string) middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return h.authorize(models.ScopeRead, h.rateLimit(route, h.limits.Read, next))
+	}
+}
+
+// scope is route which requires scope
+func (h *Handlers) scope(scope models.Scope) middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return h.authorize(scope, next)
+	}
+}
+
+// handleContextError writes status for canceled or timed out request, returns false for other errors
+func handleContextError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.WarnContext(r.Context(), "request timeout", logging.Err(err))
+		http.Error(w, "timeout", http.StatusGatewayTimeout)
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		w.WriteHeader(statusClientClosedRequest)
+		return true
+	}
+	return false
+}
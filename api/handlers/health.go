@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	statusOK       = "ok"
+	statusNotReady = "not ready"
+)
+
+type ReadyResp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type StatusResp struct {
+	ReadyResp
+	Streams int            `json:"active_streams"`
+	Details map[string]any `json:"details"`
+}
+
+// Healthz is liveness probe, process which serves requests is alive
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(statusOK))
+}
+
+// Readyz is readiness probe, it fails if dependency is unhealthy or service is shutting down
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	resp, code := h.ready(r)
+	writeJSON(w, code, resp)
+}
+
+// Status returns readiness checks with details of dependencies
+func (h *Handlers) Status(w http.ResponseWriter, r *http.Request) {
+	ready, code := h.ready(r)
+	active, _ := h.streams.Usage()
+	writeJSON(w, code, StatusResp{
+		ReadyResp: ready,
+		Streams:   active,
+		Details:   h.health.Info(),
+	})
+}
+
+func (h *Handlers) ready(r *http.Request) (ReadyResp, int) {
+	result := h.health.Ready(r.Context())
+	if !result.Ready {
+		return ReadyResp{Status: statusNotReady, Checks: result.Checks}, http.StatusServiceUnavailable
+	}
+	return ReadyResp{Status: statusOK, Checks: result.Checks}, http.StatusOK
+}
+
+func writeJSON(w http.ResponseWriter, code int, resp any) {
+	json, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(json)
+}
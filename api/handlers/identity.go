@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"slices"
+	"webhooker/internal/logging"
+)
+
+// ClientAuth of webhook route, client certificate is verified by tls server
+type ClientAuth struct {
+	Required bool
+	// identities allowed to send webhooks, empty allows any verified client
+	Allowed []string
+}
+
+type clientIdentityKey struct{}
+
+// ClientIdentity returns identity of verified client certificate, it is set only for routes which require it
+func ClientIdentity(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(clientIdentityKey{}).(string)
+	return identity, ok
+}
+
+// certIdentity is common name, or the first dns name for certificates without it
+func certIdentity(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+func (h *Handlers) requireClientCert(next http.HandlerFunc) http.HandlerFunc {
+	if !h.webhookAuth.Required {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		// certificate is optional on handshake, verified chains are empty without it
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		identity := certIdentity(r.TLS.VerifiedChains[0][0])
+		if len(h.webhookAuth.Allowed) > 0 && !slices.Contains(h.webhookAuth.Allowed, identity) {
+			slog.WarnContext(r.Context(), "client isn't allowed to send webhooks", slog.String(logging.KeyClientID, identity))
+			http.Error(w, "client isn't allowed", http.StatusForbidden)
+			return
+		}
+		ctx := logging.With(r.Context(), slog.String(logging.KeyClientID, identity))
+		next(w, r.WithContext(context.WithValue(ctx, clientIdentityKey{}, identity)))
+	}
+}
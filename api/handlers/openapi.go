@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+//go:embed openapi.json
+var openapiJSON []byte
+
+const webhookRoute = "POST /webhooks/payments/orders"
+
+// spec is loaded once, embedded document is validated by tests
+var spec = mustLoadSpec()
+
+func mustLoadSpec() *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiJSON)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load openapi spec, err: %s", err))
+	}
+	return doc
+}
+
+// OpenAPI serves specification of api
+func (h *Handlers) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openapiJSON)
+}
+
+// validate checks path, query and body of request by operation of pattern in spec.
+// Auth isn't checked by spec, it's done by authorize
+func (h *Handlers) validate(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	route, ok := specRoute(pattern)
+	if !ok {
+		panic(fmt.Sprintf("route %s isn't described in openapi spec", pattern))
+	}
+	opts := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		// providers may send json body without content type, handlers always decode json
+		if r.ContentLength != 0 && r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		params := make(map[string]string)
+		for _, p := range route.Operation.Parameters {
+			if p.Value != nil && p.Value.In == openapi3.ParameterInPath {
+				params[p.Value.Name] = r.PathValue(p.Value.Name)
+			}
+		}
+		err := openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options:    opts,
+		})
+		if err != nil {
+			if pattern == webhookRoute {
+				countWebhook(metrics.OutcomeInvalid, "")
+			}
+			slog.InfoContext(r.Context(), "invalid request", logging.Err(err))
+			http.Error(w, requestError(err), http.StatusBadRequest)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// specRoute finds operation of mux pattern like "GET /orders/{order_id}"
+func specRoute(pattern string) (*routers.Route, bool) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return nil, false
+	}
+	item := spec.Paths.Value(path)
+	if item == nil {
+		return nil, false
+	}
+	op := item.GetOperation(method)
+	if op == nil {
+		return nil, false
+	}
+	return &routers.Route{Spec: spec, Path: path, PathItem: item, Method: method, Operation: op}, true
+}
+
+// requestError is short description of invalid fields, full error has the whole schema
+func requestError(err error) string {
+	var msgs []string
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case openapi3.MultiError:
+			for _, err := range e {
+				walk(err)
+			}
+		case *openapi3filter.RequestError:
+			if e.Parameter != nil {
+				msgs = append(msgs, fmt.Sprintf("invalid %s %q: %s", e.Parameter.In, e.Parameter.Name, reason(e.Err)))
+				return
+			}
+			if e.Err != nil {
+				walk(e.Err)
+				return
+			}
+			msgs = append(msgs, e.Reason)
+		case *openapi3.SchemaError:
+			field := strings.Join(e.JSONPointer(), ".")
+			if field == "" {
+				msgs = append(msgs, "invalid body: "+e.Reason)
+			} else {
+				msgs = append(msgs, fmt.Sprintf("invalid field %q: %s", field, e.Reason))
+			}
+		default:
+			msgs = append(msgs, err.Error())
+		}
+	}
+	walk(err)
+	return strings.Join(msgs, "; ")
+}
+
+func reason(err error) string {
+	if e, ok := err.(*openapi3.SchemaError); ok {
+		return e.Reason
+	}
+	if e, ok := err.(openapi3.MultiError); ok && len(e) > 0 {
+		return reason(e[0])
+	}
+	if err == nil {
+		return "required"
+	}
+	return err.Error()
+}
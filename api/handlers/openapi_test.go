@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Spec_Valid(t *testing.T) {
+	require.NoError(t, spec.Validate(context.Background()))
+}
+
+// Test_Spec_Routes fails if route is added to GetHandlers without spec or spec has removed route
+func Test_Spec_Routes(t *testing.T) {
+	h := NewHandler(nil, nil, nil, Options{StreamTokens: &services.StreamTokenService{}})
+	h.GetHandlers()
+
+	var operations []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	routes := slices.Clone(h.routes)
+	sort.Strings(routes)
+	assert.Equal(t, operations, routes)
+}
+
+// Test_Spec_Schemas fails if json fields of request or response differ from schema properties
+func Test_Spec_Schemas(t *testing.T) {
+	schemas := map[string]any{
+		"WebhookEvent":       WebhookEvent{},
+		"Order":              OrderResp{},
+		"OrdersPage":         OrdersPageResp{},
+		"Event":              EventResp{},
+		"Timeline":           TimelineResp{},
+		"TimelineEvent":      TimelineEventResp{},
+		"StatusCounts":       StatusCountsResp{},
+		"Bucket":             BucketResp{},
+		"Conversion":         ConversionResp{},
+		"Percentiles":        PercentilesResp{},
+		"OrderStats":         OrderStatsResp{},
+		"ExportOrder":        ExportOrderResp{},
+		"ExportEvent":        ExportEventResp{},
+		"StreamTokenRequest": StreamTokenReq{},
+		"StreamToken":        StreamTokenResp{},
+		"Usage":              UsageResp{},
+		"RateLimitUsage":     RateLimitUsageResp{},
+		"ClientUsage":        ClientUsageResp{},
+		"StreamsUsage":       StreamsUsageResp{},
+		"Ready":              ReadyResp{},
+		"Status":             StatusResp{},
+	}
+	for name, v := range schemas {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := spec.Components.Schemas[name]
+			require.True(t, ok, "schema %s isn't in spec", name)
+			assert.ElementsMatch(t, schemaProperties(schema.Value), jsonFields(reflect.TypeOf(v)))
+		})
+	}
+}
+
+func Test_Spec_Statuses(t *testing.T) {
+	var statuses []string
+	for status := range models.StatusPriority {
+		statuses = append(statuses, status)
+	}
+	var enum []string
+	for _, v := range spec.Components.Schemas["OrderStatus"].Value.Enum {
+		enum = append(enum, v.(string))
+	}
+	assert.ElementsMatch(t, statuses, enum)
+}
+
+func Test_Validate(t *testing.T) {
+	validEvent := `{"event_id":"e1","order_id":"o1","user_id":"u1","order_status":"cool_order_created",` +
+		`"created_at":"2024-08-01T10:00:00Z","updated_at":"2024-08-01T10:00:00Z"}`
+	testCases := []struct {
+		name    string
+		pattern string
+		req     *http.Request
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "valid webhook",
+			pattern: webhookRoute,
+			req:     httptest.NewRequest(http.MethodPost, "/webhooks/payments/orders", strings.NewReader(validEvent)),
+			expCode: http.StatusOK,
+		},
+		{
+			name:    "missing field",
+			pattern: webhookRoute,
+			req: httptest.NewRequest(http.MethodPost, "/webhooks/payments/orders",
+				strings.NewReader(strings.Replace(validEvent, `"event_id":"e1",`, "", 1))),
+			expCode: http.StatusBadRequest,
+			expBody: `property "event_id" is missing`,
+		},
+		{
+			name:    "unknown status",
+			pattern: webhookRoute,
+			req: httptest.NewRequest(http.MethodPost, "/webhooks/payments/orders",
+				strings.NewReader(strings.Replace(validEvent, "cool_order_created", "delivered", 1))),
+			expCode: http.StatusBadRequest,
+			expBody: `invalid field "order_status"`,
+		},
+		{
+			name:    "timestamp isn't RFC3339",
+			pattern: webhookRoute,
+			req: httptest.NewRequest(http.MethodPost, "/webhooks/payments/orders",
+				strings.NewReader(strings.Replace(validEvent, `"created_at":"2024-08-01T10:00:00Z"`, `"created_at":"2024-08-01 10:00"`, 1))),
+			expCode: http.StatusBadRequest,
+			expBody: `invalid field "created_at"`,
+		},
+		{
+			name:    "valid query",
+			pattern: "GET /orders",
+			req:     httptest.NewRequest(http.MethodGet, "/orders?status=chinazes,failed&limit=10&created_from=2024-08-01T10:00:00Z", nil),
+			expCode: http.StatusOK,
+		},
+		{
+			name:    "unknown status in query",
+			pattern: "GET /orders",
+			req:     httptest.NewRequest(http.MethodGet, "/orders?status=chinazes,delivered", nil),
+			expCode: http.StatusBadRequest,
+			expBody: `invalid query "status"`,
+		},
+		{
+			name:    "timestamp in query isn't RFC3339",
+			pattern: "GET /orders",
+			req:     httptest.NewRequest(http.MethodGet, "/orders?updated_to=yesterday", nil),
+			expCode: http.StatusBadRequest,
+			expBody: `invalid query "updated_to"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(nil, nil, nil, Options{})
+			next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+			w := httptest.NewRecorder()
+
+			h.validate(tc.pattern, next)(w, tc.req)
+
+			assert.Equal(t, tc.expCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expBody)
+		})
+	}
+}
+
+// schemaProperties returns properties of object schema and schemas of allOf
+func schemaProperties(schema *openapi3.Schema) []string {
+	var props []string
+	for name := range schema.Properties {
+		props = append(props, name)
+	}
+	for _, s := range schema.AllOf {
+		props = append(props, schemaProperties(s.Value)...)
+	}
+	return props
+}
+
+// jsonFields returns json names of struct fields, fields of embedded structs are inlined
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	return fields
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+	"webhooker/internal/logging"
 	"webhooker/internal/services"
 	"webhooker/internal/services/models"
 )
@@ -18,9 +21,11 @@ type OrderResp struct {
 	IsFinal  bool   `json:"is_final"`
 	CreateAt string `json:"created_at"`
 	UpdateAt string `json:"updated_at"`
+	Version  int    `json:"version"`
 }
 
-func orderToOrderResp(order *models.Order) OrderResp {
+// OrderToOrderResp is shared with cli, so json output of commands matches api
+func OrderToOrderResp(order *models.Order) OrderResp {
 	return OrderResp{
 		OrderID:  order.ID,
 		UserID:   order.UserID,
@@ -28,127 +33,206 @@ func orderToOrderResp(order *models.Order) OrderResp {
 		IsFinal:  order.IsFinal,
 		CreateAt: order.CreateAt.Format(timeLayout),
 		UpdateAt: order.UpdateAt.Format(timeLayout),
+		Version:  order.Version,
 	}
 }
 
 func (h *Handlers) GetOrders(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// cursor pagination, empty cursor requests the first page
+	if r.URL.Query().Has("cursor") {
+		h.getOrdersByCursor(w, r, filter, r.URL.Query().Get("cursor"))
+		return
+	}
+
+	orders, err := h.order.GetOrders(r.Context(), filter)
+	if err != nil {
+		handleOrdersError(w, r, err)
+		return
+	}
+
+	OrdersResp := make([]OrderResp, 0, len(orders))
+	for _, order := range orders {
+		OrdersResp = append(OrdersResp, OrderToOrderResp(order))
+	}
+
+	json, err := json.Marshal(OrdersResp)
+	if err != nil {
+		http.Error(w, "failed to marshal user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+type OrdersPageResp struct {
+	Orders     []OrderResp `json:"orders"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+func (h *Handlers) getOrdersByCursor(w http.ResponseWriter, r *http.Request, filter *models.OrderFilter, cursor string) {
+	orders, nextCursor, err := h.order.GetOrdersByCursor(r.Context(), filter, cursor)
+	if err != nil {
+		handleOrdersError(w, r, err)
+		return
+	}
+
+	page := OrdersPageResp{
+		Orders: make([]OrderResp, 0, len(orders)),
+	}
+	for _, order := range orders {
+		page.Orders = append(page.Orders, OrderToOrderResp(order))
+	}
+	if nextCursor != "" {
+		page.NextCursor = &nextCursor
+	}
+
+	json, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, "failed to marshal orders", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+func handleOrdersError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrFilterRequired) ||
+		errors.Is(err, services.ErrInvalidTimeRange) ||
+		errors.Is(err, services.ErrTooManyIDs) ||
+		errors.Is(err, services.ErrUnsupportedStatus) ||
+		errors.Is(err, services.ErrInvalidCursor) ||
+		errors.Is(err, services.ErrCursorWithOffset) ||
+		errors.Is(err, services.ErrCursorLimit) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if handleContextError(w, r, err) {
+		return
+	}
+	slog.ErrorContext(r.Context(), "failed to get orders", logging.Err(err))
+	http.Error(w, "error", http.StatusInternalServerError)
+}
+
+// parseOrderFilter reads filter from query params, returned error is safe to show to client
+func parseOrderFilter(query url.Values) (*models.OrderFilter, error) {
 	// status
-	statusStr := r.URL.Query().Get("status")
+	statusStr := query.Get("status")
 	var statuses []string
 	if statusStr != "" {
 		statusStr = strings.ReplaceAll(statusStr, " ", "")
 		statuses = strings.Split(statusStr, ",")
 	}
-	// user_id
-	userIdStr := r.URL.Query().Get("user_id")
-	var userId *string
-	if userIdStr != "" {
-		userId = &userIdStr
+	// user_id, order_id
+	userIds := parseList(query.Get("user_id"))
+	orderIds := parseList(query.Get("order_id"))
+	// created_from, created_to, updated_from, updated_to
+	var timeRange [4]*time.Time
+	for i, name := range []string{"created_from", "created_to", "updated_from", "updated_to"} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(timeLayout, value)
+		if err != nil {
+			return nil, errors.New("invalid " + name)
+		}
+		timeRange[i] = &t
 	}
 	// limit
-	limitStr := r.URL.Query().Get("limit")
+	limitStr := query.Get("limit")
 	var limit *int
 	if limitStr != "" {
 		l, err := strconv.Atoi(limitStr)
 		if err != nil {
-			http.Error(w, "invalid limit", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid limit")
 		}
 		if l < 0 {
-			http.Error(w, "invalid limit", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid limit")
 		}
 		limit = &l
 	}
 	// offset
-	offsetStr := r.URL.Query().Get("offset")
+	offsetStr := query.Get("offset")
 	var offset *int
 	if offsetStr != "" {
 		o, err := strconv.Atoi(offsetStr)
 		if err != nil {
-			http.Error(w, "invalid offset", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid offset")
 		}
 		if o < 0 {
-			http.Error(w, "invalid offset", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid offset")
 		}
 		offset = &o
 	}
 	// is_final
-	isFinalStr := r.URL.Query().Get("isFinal")
+	isFinalStr := query.Get("isFinal")
 	var isFinal *bool
 	if isFinalStr != "" {
 		b, err := strconv.ParseBool(isFinalStr)
 		if err != nil {
-			http.Error(w, "invalid isFinal", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid isFinal")
 		}
 		isFinal = &b
 	}
 	// sort_by
-	sortByStr := r.URL.Query().Get("sort_by")
+	sortByStr := query.Get("sort_by")
 	var sortBy *models.SortBy
 	if sortByStr != "" {
 		if sortByStr != string(models.CreateAt) && sortByStr != string(models.UpdateAt) {
-			http.Error(w, "invalid sort_by", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid sort_by")
 		}
 		s := models.SortBy(sortByStr)
 		sortBy = &s
 	}
 	// sort_order
-	sortOrderStr := r.URL.Query().Get("sort_order")
+	sortOrderStr := query.Get("sort_order")
 	var sortOrder *models.SortOrder
 	if sortOrderStr != "" {
 		if sortOrderStr != string(models.SortAsc) && sortOrderStr != string(models.SortDesc) {
-			http.Error(w, "invalid sort_order", http.StatusBadRequest)
-			return
+			return nil, errors.New("invalid sort_order")
 		}
 		s := models.SortOrder(sortOrderStr)
 		sortOrder = &s
 	}
 
-	orders, err := h.order.GetOrders(&models.OrderFilter{
-		Status:    statuses,
-		UserID:    userId,
-		Limit:     limit,
-		Offset:    offset,
-		IsFinal:   isFinal,
-		SortBy:    sortBy,
-		SortOrder: sortOrder,
-	})
+	return &models.OrderFilter{
+		Status:      statuses,
+		UserIDs:     userIds,
+		OrderIDs:    orderIds,
+		CreatedFrom: timeRange[0],
+		CreatedTo:   timeRange[1],
+		UpdatedFrom: timeRange[2],
+		UpdatedTo:   timeRange[3],
+		Limit:       limit,
+		Offset:      offset,
+		IsFinal:     isFinal,
+		SortBy:      sortBy,
+		SortOrder:   sortOrder,
+	}, nil
+}
 
-	if err != nil {
-		if errors.Is(err, services.ErrFilterStatus) {
-			http.Error(w, "provide isFinal or status", http.StatusBadRequest)
-			return
-		}
-		if errors.Is(err, services.ErrOnlyOneRequired) {
-			http.Error(w, "provide only isFinal or only Status", http.StatusBadRequest)
-			return
+// parseList splits comma separated values, empty values are skipped
+func parseList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
 		}
-		if errors.Is(err, services.ErrUnsupportedStatus) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		log.Printf("failed to get orders err: %s", err.Error())
-		http.Error(w, "error", http.StatusInternalServerError)
-		return
 	}
-
-	OrdersResp := make([]OrderResp, 0, len(orders))
-	for _, order := range orders {
-		OrdersResp = append(OrdersResp, orderToOrderResp(order))
-	}
-
-	json, err := json.Marshal(OrdersResp)
-	if err != nil {
-		http.Error(w, "failed to marshal user", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(json)
+	return list
 }
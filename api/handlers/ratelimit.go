@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"webhooker/internal/ratelimit"
+	"webhooker/internal/services"
+)
+
+// client can retry after this time if it has too many streams, stream can last for minutes
+const streamRetryAfter = 5 * time.Second
+
+// Limits of clients, zero value disables them
+type Limits struct {
+	Webhook ratelimit.Limit
+	Read    ratelimit.Limit
+	// StreamsPerUser limits concurrent streams of one client, Streams limits all of them
+	StreamsPerUser int
+	Streams        int
+}
+
+// rateLimit limits requests of every client to route, it should be called after authorize to know client.
+// Handlers of the same route share limiter
+func (h *Handlers) rateLimit(route string, limit ratelimit.Limit, next http.HandlerFunc) http.HandlerFunc {
+	if limit.Rate <= 0 {
+		return next
+	}
+	limiter, ok := h.limiters[route]
+	if !ok {
+		limiter = ratelimit.NewLimiter(limit)
+		h.limiters[route] = limiter
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.Allow(clientKey(r)); !ok {
+			tooManyRequests(w, retryAfter)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// limitStreams limits concurrent events streams, every stream holds goroutines and broker subscription
+func (h *Handlers) limitStreams(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, ok := h.streams.Acquire(clientKey(r))
+		if !ok {
+			tooManyRequests(w, streamRetryAfter)
+			return
+		}
+		defer release()
+		next(w, r)
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+}
+
+// clientKey identifies client: api key or end user, client certificate or ip.
+// Stream token is identified by its issuer, token issued without auth by ip
+func clientKey(r *http.Request) string {
+	principal := services.PrincipalFromContext(r.Context())
+	if principal != nil && principal.Issuer != nil {
+		principal = principal.Issuer
+	}
+	if principal != nil && principal.IsUser() {
+		return "user:" + principal.UserID
+	}
+	if principal != nil && principal.Name != "" {
+		return "key:" + principal.Name
+	}
+	if identity, ok := ClientIdentity(r.Context()); ok {
+		return "cert:" + identity
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+type UsageResp struct {
+	RateLimits map[string]RateLimitUsageResp `json:"rate_limits"`
+	Streams    StreamsUsageResp              `json:"streams"`
+}
+
+type RateLimitUsageResp struct {
+	Rate    float64           `json:"rate"`
+	Burst   int               `json:"burst"`
+	Clients []ClientUsageResp `json:"clients"`
+}
+
+// ClientUsageResp has used part of burst, client is limited when it reaches burst
+type ClientUsageResp struct {
+	Client string  `json:"client"`
+	Used   float64 `json:"used"`
+}
+
+type StreamsUsageResp struct {
+	Active       int            `json:"active"`
+	Limit        int            `json:"limit"`
+	PerUserLimit int            `json:"per_user_limit"`
+	Clients      map[string]int `json:"clients"`
+}
+
+// GetUsage returns current usage of rate limits and streams
+func (h *Handlers) GetUsage(w http.ResponseWriter, r *http.Request) {
+	resp := UsageResp{RateLimits: make(map[string]RateLimitUsageResp, len(h.limiters))}
+	for route, limiter := range h.limiters {
+		usage := limiter.Usage()
+		clients := make([]ClientUsageResp, 0, len(usage))
+		for _, u := range usage {
+			clients = append(clients, ClientUsageResp{Client: u.Key, Used: u.Used})
+		}
+		resp.RateLimits[route] = RateLimitUsageResp{
+			Rate:    limiter.Limit().Rate,
+			Burst:   limiter.Limit().Burst,
+			Clients: clients,
+		}
+	}
+	resp.Streams.Active, resp.Streams.Clients = h.streams.Usage()
+	resp.Streams.PerUserLimit, resp.Streams.Limit = h.streams.Limits()
+
+	json, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, "failed to marshal usage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
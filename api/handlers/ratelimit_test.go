@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_ClientKey_StreamToken checks that tokens of one client share its streams limit
+func Test_ClientKey_StreamToken(t *testing.T) {
+	tokens := services.NewStreamTokenService(nil, []byte("0123456789abcdef0123456789abcdef"), time.Minute, time.Hour, services.Timeouts{})
+	h := NewHandler(nil, nil, nil, Options{StreamTokens: tokens, Limits: Limits{StreamsPerUser: 1}})
+
+	issue := func(principal *models.Principal, userID string) string {
+		value, _, err := tokens.IssueToken(services.WithPrincipal(context.Background(), principal), "", userID, 0)
+		require.Nil(t, err)
+		return value
+	}
+	user1 := &models.Principal{Name: "sub1", UserID: "user1", Scopes: []models.Scope{models.ScopeRead}}
+	user2 := &models.Principal{Name: "sub2", UserID: "user2", Scopes: []models.Scope{models.ScopeRead}}
+	service := &models.Principal{Name: "service", Scopes: []models.Scope{models.ScopeRead}}
+
+	keys := make(chan string, 1)
+	release := make(chan struct{})
+	handler := h.authorizeStream(h.limitStreams(func(w http.ResponseWriter, r *http.Request) {
+		keys <- clientKey(r)
+		<-release
+	}))
+	stream := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/orders/order1/events?token="+url.QueryEscape(token), nil))
+		return w
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream(issue(user1, "user1"))
+	}()
+	assert.Equal(t, "user:user1", <-keys)
+
+	// other token of the same user hits limit of the user
+	assert.Equal(t, http.StatusTooManyRequests, stream(issue(user1, "user1")).Code)
+	// token issued by service for the user is limited as service
+	go stream(issue(service, "user1"))
+	assert.Equal(t, "key:service", <-keys)
+	go stream(issue(user2, "user2"))
+	assert.Equal(t, "user:user2", <-keys)
+
+	close(release)
+	<-done
+}
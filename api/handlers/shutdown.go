@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// client reconnects to stream after this time, load balancer sends it to other instance
+	shutdownReconnect = time.Second
+	shutdownMessage   = "server is shutting down, reconnect"
+)
+
+// Drain stops accepting webhooks and closes events streams with terminal message,
+// requests which are in progress are finished by server shutdown
+func (h *Handlers) Drain() {
+	h.drainOnce.Do(func() { close(h.draining) })
+}
+
+// acceptWebhooks rejects webhooks after Drain, provider retries them on other instance
+func (h *Handlers) acceptWebhooks(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-h.draining:
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", strconv.Itoa(int(shutdownReconnect.Seconds())))
+			http.Error(w, shutdownMessage, http.StatusServiceUnavailable)
+			return
+		default:
+		}
+		next(w, r)
+	}
+}
+
+// writeShutdown sends terminal message of events stream, retry sets reconnection delay of EventSource
+func writeShutdown(w http.ResponseWriter, flusher http.Flusher) {
+	fmt.Fprintf(w, "retry: %d\nevent: shutdown\ndata: %s\n\n", shutdownReconnect.Milliseconds(), shutdownMessage)
+	flusher.Flush()
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+)
+
+type StatusCountsResp struct {
+	Total    int            `json:"total"`
+	Final    int            `json:"final"`
+	NonFinal int            `json:"non_final"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+type BucketResp struct {
+	Start string `json:"start"`
+	StatusCountsResp
+}
+
+type ConversionResp struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// PercentilesResp values are in seconds
+type PercentilesResp struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+type OrderStatsResp struct {
+	StatusCountsResp
+	Reached      map[string]int             `json:"reached"`
+	Conversions  []ConversionResp           `json:"conversions"`
+	TimeInStatus map[string]PercentilesResp `json:"time_in_status"`
+	Buckets      []BucketResp               `json:"buckets,omitempty"`
+}
+
+func statusCountsToResp(sc models.StatusCounts) StatusCountsResp {
+	return StatusCountsResp{
+		Total:    sc.Total,
+		Final:    sc.Final,
+		NonFinal: sc.NonFinal,
+		Statuses: sc.Statuses,
+	}
+}
+
+func statsToStatsResp(stats *models.OrderStats) OrderStatsResp {
+	resp := OrderStatsResp{
+		StatusCountsResp: statusCountsToResp(stats.Counts),
+		Reached:          stats.Reached,
+		Conversions:      make([]ConversionResp, 0, len(stats.Conversions)),
+		TimeInStatus:     make(map[string]PercentilesResp, len(stats.TimeInStatus)),
+	}
+	for _, c := range stats.Conversions {
+		resp.Conversions = append(resp.Conversions, ConversionResp{From: c.From, To: c.To, Rate: c.Rate})
+	}
+	for status, p := range stats.TimeInStatus {
+		resp.TimeInStatus[status] = PercentilesResp{
+			Count: p.Count,
+			P50:   p.P50.Seconds(),
+			P90:   p.P90.Seconds(),
+			P99:   p.P99.Seconds(),
+		}
+	}
+	for _, b := range stats.Buckets {
+		resp.Buckets = append(resp.Buckets, BucketResp{
+			Start:            b.Start.Format(timeLayout),
+			StatusCountsResp: statusCountsToResp(b.StatusCounts),
+		})
+	}
+	return resp
+}
+
+func (h *Handlers) GetOrderStats(w http.ResponseWriter, r *http.Request) {
+	filter := &models.StatsFilter{
+		UserIDs: parseList(r.URL.Query().Get("user_id")),
+		Bucket:  models.Bucket(r.URL.Query().Get("bucket")),
+	}
+	// from, to
+	for _, p := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		value := r.URL.Query().Get(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(timeLayout, value)
+		if err != nil {
+			http.Error(w, "invalid "+p.name, http.StatusBadRequest)
+			return
+		}
+		*p.value = &t
+	}
+
+	stats, err := h.stats.GetOrderStats(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTimeRange) ||
+			errors.Is(err, services.ErrTooManyIDs) ||
+			errors.Is(err, services.ErrUnsupportedBucket) ||
+			errors.Is(err, services.ErrBucketRange) ||
+			errors.Is(err, services.ErrStatsRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if handleContextError(w, r, err) {
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to get order stats", logging.Err(err))
+		http.Error(w, "error", http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(statsToStatsResp(stats))
+	if err != nil {
+		http.Error(w, "failed to marshal stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
+	"webhooker/internal/services"
 	"webhooker/internal/services/models"
 )
 
@@ -23,37 +28,65 @@ func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics.ActiveStreams.Inc()
+	defer metrics.ActiveStreams.Dec()
+
 	eventCh, done, errCh := h.stream.GetEventStream(r.Context(), orderId)
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	h.allowOrigin(w, r)
 
 	for {
 		select {
-		case event := <-eventCh:
+		case event, ok := <-eventCh:
+			if !ok {
+				closeStream(w, r, flusher)
+				return
+			}
 			eventResp := eventToEventResp(event)
 			jsonData, _ := json.Marshal(eventResp)
-			log.Printf(">>> StreamEvents. [%s] data: %s\n\n", event.OrderStatus, jsonData)
 			fmt.Fprintf(w, "data: %s\n\n", jsonData)
 			flusher.Flush()
 		case <-done:
-			log.Printf("(!) StreamEvents. close connection\n")
+			closeStream(w, r, flusher)
+			return
+		case <-h.draining:
+			writeShutdown(w, flusher)
 			return
-		case err := <-errCh:
-			log.Printf("(!) StreamEvents. failed to get events stream, err: %s\n", err.Error())
+		case err, ok := <-errCh:
+			if !ok {
+				closeStream(w, r, flusher)
+				return
+			}
+			if errors.Is(err, services.ErrOrderNotFound) {
+				http.Error(w, "order not found", http.StatusNotFound)
+				return
+			}
+			slog.ErrorContext(r.Context(), "failed to get events stream", logging.Err(err))
 			http.Error(w, "failed to get events stream", http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
+// closeStream is called when stream is done or its channels are closed after cancel of request
+func closeStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher) {
+	// client knows why stream is closed and doesn't reconnect with the same token
+	if cause := context.Cause(r.Context()); errors.Is(cause, services.ErrStreamTokenExpired) || errors.Is(cause, services.ErrStreamTokenRevoked) {
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", cause.Error())
+		flusher.Flush()
+	}
+	slog.DebugContext(r.Context(), "events stream is closed")
+}
+
 type EventResp struct {
 	OrderId  string `json:"order_id"`
 	UserId   string `json:"user_id"`
 	Status   string `json:"order_status"`
 	IsFinal  bool   `json:"is_final"`
+	IsSystem bool   `json:"is_system"`
 	CreateAt string `json:"created_at"`
 	UpdateAt string `json:"updated_at"`
 }
@@ -64,6 +97,7 @@ func eventToEventResp(e *models.Event) EventResp {
 		UserId:   e.UserID,
 		Status:   e.OrderStatus,
 		IsFinal:  e.IsFinal,
+		IsSystem: e.IsSystem,
 		CreateAt: e.CreateAt.Format(timeLayout),
 		UpdateAt: e.UpdateAt.Format(timeLayout),
 	}
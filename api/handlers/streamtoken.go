@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+)
+
+// stream token is passed in query, browser EventSource can't set headers
+const streamTokenParam = "token"
+
+type StreamTokenReq struct {
+	OrderID string `json:"order_id"`
+	UserID  string `json:"user_id"`
+	// TTL is duration like 5m, default ttl is used if it's empty
+	TTL string `json:"ttl"`
+}
+
+type StreamTokenResp struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	// URL of events stream, only for token of order
+	URL string `json:"url,omitempty"`
+}
+
+func (h *Handlers) IssueStreamToken(w http.ResponseWriter, r *http.Request) {
+	var req StreamTokenReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+	}
+
+	value, token, err := h.tokens.IssueToken(r.Context(), req.OrderID, req.UserID, ttl)
+	if err != nil {
+		if errors.Is(err, services.ErrStreamTokenScope) || errors.Is(err, services.ErrStreamTokenTTL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, services.ErrOrderNotFound) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		if handleContextError(w, r, err) {
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to issue stream token", logging.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := StreamTokenResp{
+		ID:        token.ID,
+		Token:     value,
+		ExpiresAt: token.ExpiresAt.UTC().Format(timeLayout),
+	}
+	if token.OrderID != "" {
+		resp.URL = fmt.Sprintf("/orders/%s/events?%s=%s", url.PathEscape(token.OrderID), streamTokenParam, url.QueryEscape(value))
+	}
+	json, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, "failed to marshal token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(json)
+}
+
+// RevokeStreamToken rejects token and closes its active streams
+func (h *Handlers) RevokeStreamToken(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("token_id")
+	if id == "" {
+		http.Error(w, "token_id can't be empty", http.StatusBadRequest)
+		return
+	}
+	if err := h.tokens.RevokeToken(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrStreamTokenNotFound) {
+			http.Error(w, "stream token not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to revoke stream token", logging.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeStream accepts stream token from query instead of bearer token,
+// stream is closed when token expires or is revoked
+func (h *Handlers) authorizeStream(next http.HandlerFunc) http.HandlerFunc {
+	authorized := h.authorize(models.ScopeRead, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has(streamTokenParam) {
+			authorized(w, r)
+			return
+		}
+
+		token, err := h.tokens.VerifyToken(r.URL.Query().Get(streamTokenParam))
+		if err != nil {
+			slog.InfoContext(r.Context(), "failed to verify stream token", logging.Err(err))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		// token of order doesn't allow other orders, token of user is limited by services
+		if token.OrderID != "" && token.OrderID != r.PathValue("order_id") {
+			http.Error(w, "token isn't valid for order", http.StatusForbidden)
+			return
+		}
+		principal := &models.Principal{
+			Name:   "stream-token:" + token.ID,
+			UserID: token.UserID,
+			Scopes: []models.Scope{models.ScopeRead},
+			// streams of all tokens of client share its rate limit and streams limit
+			Issuer: &models.Principal{Name: token.Issuer, UserID: token.IssuerUserID},
+		}
+
+		ctx, cancel := h.tokens.StreamContext(withPrincipal(r.Context(), principal), token)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"webhooker/internal/logging"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+)
+
+type TimelineResp struct {
+	Order  OrderResp           `json:"order"`
+	Events []TimelineEventResp `json:"events"`
+}
+
+type TimelineEventResp struct {
+	EventID    string  `json:"event_id"`
+	Status     string  `json:"order_status"`
+	IsFinal    bool    `json:"is_final"`
+	IsSystem   bool    `json:"is_system"`
+	IsStreamed bool    `json:"is_streamed"`
+	CreateAt   string  `json:"created_at"`
+	UpdateAt   string  `json:"updated_at"`
+	ReceivedAt *string `json:"received_at"`
+	// seconds till next event, null for the last event
+	TimeInStatus *float64 `json:"time_in_status"`
+}
+
+// TimelineToTimelineResp is shared with cli inspect command
+func TimelineToTimelineResp(t *models.Timeline) TimelineResp {
+	resp := TimelineResp{
+		Order:  OrderToOrderResp(t.Order),
+		Events: make([]TimelineEventResp, 0, len(t.Events)),
+	}
+	for _, te := range t.Events {
+		e := te.Event
+		eventResp := TimelineEventResp{
+			EventID:    e.EventID,
+			Status:     e.OrderStatus,
+			IsFinal:    e.IsFinal,
+			IsSystem:   e.IsSystem,
+			IsStreamed: te.IsStreamed,
+			CreateAt:   e.CreateAt.Format(timeLayout),
+			UpdateAt:   e.UpdateAt.Format(timeLayout),
+		}
+		if !e.ReceivedAt.IsZero() {
+			receivedAt := e.ReceivedAt.Format(timeLayout)
+			eventResp.ReceivedAt = &receivedAt
+		}
+		if te.TimeInStatus != nil {
+			seconds := te.TimeInStatus.Seconds()
+			eventResp.TimeInStatus = &seconds
+		}
+		resp.Events = append(resp.Events, eventResp)
+	}
+	return resp
+}
+
+func (h *Handlers) GetOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("order_id")
+
+	timeline, err := h.order.GetTimeline(r.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, services.ErrOrderNotFound) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		if handleContextError(w, r, err) {
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to get order timeline", logging.Err(err))
+		http.Error(w, "error", http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(TimelineToTimelineResp(timeline))
+	if err != nil {
+		http.Error(w, "failed to marshal timeline", http.StatusInternalServerError)
+		return
+	}
+
+	// timeline doesn't depend on request time, so hash of body changes only with order or events
+	sum := sha256.Sum256(json)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// etagMatch uses weak comparison as required for If-None-Match
+func etagMatch(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -3,9 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
 	"webhooker/internal/services/models"
 )
 
@@ -19,38 +21,65 @@ type WebhookEvent struct {
 }
 
 func (h *Handlers) ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	receivedAt := time.Now().UTC()
+
 	var webhookEvent WebhookEvent
 	dec := json.NewDecoder(r.Body)
 	err := dec.Decode(&webhookEvent)
 	if err != nil {
+		countWebhook(metrics.OutcomeInvalid, "")
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
-	event, err := webhookToEvent(&webhookEvent)
+	event, err := WebhookToEvent(&webhookEvent)
 	if err != nil {
+		countWebhook(metrics.OutcomeInvalid, webhookEvent.OrderStatus)
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
+	event.ReceivedAt = receivedAt
 
-	err = h.stream.SaveEvent(event)
+	err = h.stream.SaveEvent(r.Context(), event)
 	if err != nil {
 		if errors.Is(err, models.ErrAlreadyExist) {
+			countWebhook(metrics.OutcomeDuplicate, event.OrderStatus)
 			http.Error(w, "", http.StatusConflict)
 			return
 		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			countWebhook(metrics.OutcomeConflict, event.OrderStatus)
+			http.Error(w, "order was changed concurrently", http.StatusConflict)
+			return
+		}
 		if errors.Is(err, models.ErrAfterFinal) {
+			countWebhook(metrics.OutcomeAfterFinal, event.OrderStatus)
 			http.Error(w, "", http.StatusGone)
 			return
 		}
-		log.Printf("failed to save event: %s", err)
+		countWebhook(metrics.OutcomeError, event.OrderStatus)
+		if handleContextError(w, r, err) {
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to save event", logging.Err(err))
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	countWebhook(metrics.OutcomeAccepted, event.OrderStatus)
+	metrics.EventLag.Observe(receivedAt.Sub(event.UpdateAt).Seconds())
 	w.WriteHeader(http.StatusOK)
 }
 
-func webhookToEvent(w *WebhookEvent) (*models.Event, error) {
+// countWebhook uses only known statuses as label, provider can send anything
+func countWebhook(outcome string, status string) {
+	if _, ok := models.StatusPriority[status]; !ok {
+		status = "unknown"
+	}
+	metrics.Webhooks.WithLabelValues(outcome, status).Inc()
+}
+
+// WebhookToEvent is shared with cli replay command, so replayed events are parsed as webhooks
+func WebhookToEvent(w *WebhookEvent) (*models.Event, error) {
 	var (
 		createAtTime time.Time
 		updateAtTime time.Time
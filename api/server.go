@@ -2,39 +2,52 @@ package api
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"strconv"
+	"webhooker/config"
 )
 
 type Server struct {
-	port   int
+	cfg    *config.ServerConfig
 	routes *http.ServeMux
 	server *http.Server
 }
 
-func NewHttpServer(port int, routes *http.ServeMux) *Server {
+func NewHttpServer(cfg *config.ServerConfig, routes *http.ServeMux) *Server {
 	return &Server{
+		cfg:    cfg,
 		routes: routes,
-		port:   port,
+		// server is created before Serve, so it can be shut down before it's started
+		server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           routes,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
 	}
-
 }
 
+// Serve blocks until server is shut down, it returns http.ErrServerClosed after Shutdown
 func (s *Server) Serve() error {
-	// start server
-	p := strconv.Itoa(s.port)
-	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%s", p),
-		Handler: s.routes}
+	if s.cfg.TLS.CertFile == "" {
+		return s.server.ListenAndServe()
+	}
 
-	err := s.server.ListenAndServe()
+	reloader, err := newCertReloader(s.cfg.TLS)
 	if err != nil {
 		return err
 	}
-	return nil
+	s.server.TLSConfig = reloader.TLSConfig()
+	// certificate is taken from TLSConfig
+	return s.server.ListenAndServeTLS("", "")
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
+
+// Close closes connections of requests which weren't finished by Shutdown
+func (s *Server) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"webhooker/config"
+	"webhooker/internal/logging"
+)
+
+// files are checked for changes not more often than once per reloadInterval
+const reloadInterval = 10 * time.Second
+
+var errNoCA = errors.New("no certificates found in client ca file")
+
+// certReloader serves certificate and client ca loaded from files, files are reloaded on change
+// during handshake, so new certificate is used without restart
+type certReloader struct {
+	cfg      config.TLSConfig
+	interval time.Duration
+
+	mu        sync.Mutex
+	tlsConfig *tls.Config
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(cfg config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{
+		cfg:      cfg,
+		interval: reloadInterval,
+	}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	r.tlsConfig, err = r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return r, nil
+}
+
+// TLSConfig for http server, actual config is returned for every client
+func (r *certReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+		// older http.Server checks only GetCertificate before serving tls
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			tlsConfig, err := r.getConfigForClient(hello)
+			if err != nil {
+				return nil, err
+			}
+			return &tlsConfig.Certificates[0], nil
+		},
+	}
+}
+
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < r.interval {
+		return r.tlsConfig, nil
+	}
+	r.checkedAt = time.Now()
+
+	modTime, err := r.lastModified()
+	if err != nil {
+		slog.Error("failed to check tls files, keep loaded certificate", logging.Err(err))
+		return r.tlsConfig, nil
+	}
+	if modTime.Equal(r.modTime) {
+		return r.tlsConfig, nil
+	}
+	// files can be partially written, broken certificate doesn't replace valid one
+	tlsConfig, err := r.load()
+	if err != nil {
+		slog.Error("failed to reload tls files, keep loaded certificate", logging.Err(err))
+		return r.tlsConfig, nil
+	}
+	slog.Info("tls certificate reloaded")
+	r.tlsConfig = tlsConfig
+	r.modTime = modTime
+	return r.tlsConfig, nil
+}
+
+func (r *certReloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate, err: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(r.cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client ca, err: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errNoCA
+	}
+	// certificate is optional on handshake, it is required only by webhook route
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	tlsConfig.ClientCAs = pool
+	return tlsConfig, nil
+}
+
+// lastModified returns the latest modification time of tls files
+func (r *certReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, name := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
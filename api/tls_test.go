@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"webhooker/api/handlers"
+	"webhooker/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile string, keyFile string) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	require.Nil(t, os.WriteFile(certFile, certPEM, 0o600))
+	if keyFile == "" {
+		return
+	}
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.Nil(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	require.Nil(t, os.WriteFile(keyFile, keyPEM, 0o600))
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func Test_CertReloader(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	ca := newTestCert(t, "ca", nil)
+	first := newTestCert(t, "first", ca)
+	first.write(t, cfg.CertFile, cfg.KeyFile)
+
+	reloader, err := newCertReloader(cfg)
+	require.Nil(t, err)
+	reloader.interval = 0
+
+	served := func() string {
+		tlsConfig, err := reloader.getConfigForClient(nil)
+		require.Nil(t, err)
+		leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+		require.Nil(t, err)
+		return leaf.Subject.CommonName
+	}
+	touch := func(at time.Time) {
+		require.Nil(t, os.Chtimes(cfg.CertFile, at, at))
+		require.Nil(t, os.Chtimes(cfg.KeyFile, at, at))
+	}
+	assert.Equal(t, "first", served())
+
+	// certificate is replaced on file change
+	newTestCert(t, "second", ca).write(t, cfg.CertFile, cfg.KeyFile)
+	touch(time.Now().Add(time.Minute))
+	assert.Equal(t, "second", served())
+
+	// broken file doesn't replace loaded certificate
+	require.Nil(t, os.WriteFile(cfg.CertFile, []byte("broken"), 0o600))
+	touch(time.Now().Add(2 * time.Minute))
+	assert.Equal(t, "second", served())
+
+	_, err = newCertReloader(cfg)
+	assert.NotNil(t, err)
+}
+
+func Test_Server_WebhookClientCert(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.ServerConfig{
+		Addr: freeAddr(t),
+		TLS: config.TLSConfig{
+			CertFile:     filepath.Join(dir, "cert.pem"),
+			KeyFile:      filepath.Join(dir, "key.pem"),
+			ClientCAFile: filepath.Join(dir, "ca.pem"),
+		},
+	}
+	ca := newTestCert(t, "ca", nil)
+	ca.write(t, cfg.TLS.ClientCAFile, "")
+	newTestCert(t, "127.0.0.1", ca).write(t, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+
+	h := handlers.NewHandler(nil, nil, nil, handlers.Options{
+		WebhookAuth: handlers.ClientAuth{Required: true, Allowed: []string{"provider"}},
+	})
+	server := NewHttpServer(cfg, h.GetHandlers())
+	go server.Serve()
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	post := func(client *testCert) int {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if client != nil {
+			tlsConfig.Certificates = []tls.Certificate{client.tlsCert()}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			var err error
+			resp, err = httpClient.Post("https://"+cfg.Addr+"/webhooks/payments/orders", "application/json", strings.NewReader("{"))
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post(nil))
+	assert.Equal(t, http.StatusForbidden, post(newTestCert(t, "other", ca)))
+	// verified provider reaches handler, broken body is rejected there
+	assert.Equal(t, http.StatusBadRequest, post(newTestCert(t, "provider", ca)))
+	// certificate signed by unknown ca fails on handshake
+	evil := newTestCert(t, "provider", newTestCert(t, "evil", nil)).tlsCert()
+	tlsConfig := &tls.Config{
+		RootCAs: roots,
+		// client skips certificate of unknown ca, force it
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &evil, nil },
+	}
+	_, err := (&http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}).Get("https://" + cfg.Addr + "/webhooks/payments/orders")
+	assert.NotNil(t, err)
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
@@ -2,18 +2,29 @@ package app
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"crypto/rand"
+	"errors"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 	"webhooker/api"
 	"webhooker/api/handlers"
 	"webhooker/config"
-	"webhooker/internal/queue/inmemory"
-	"webhooker/internal/schedule/delay"
+	"webhooker/internal/auth"
+	"webhooker/internal/health"
+	"webhooker/internal/lifecycle"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
+	"webhooker/internal/ratelimit"
 	"webhooker/internal/services"
-	"webhooker/internal/storage/posgres"
+	"webhooker/internal/storage/archive"
+	"webhooker/internal/tracing"
+)
+
+const (
+	timeLayout = time.RFC3339
 )
 
 type App struct {
@@ -21,49 +32,175 @@ type App struct {
 }
 
 func (a *App) Run() {
-	log.Printf("App started\n")
+	slog.Info("app started")
 
-	dbClient, err := posgres.NewPgClient(&a.Config.Postgress)
+	shutdownTracing, err := tracing.Setup(context.Background(), &a.Config.Tracing)
 	if err != nil {
-		log.Fatal("failed to create db client %w", err)
+		fatal("failed to setup tracing", err)
 	}
 
-	eventStorage := posgres.NewEventStorage(dbClient)
-	orderStorage := posgres.NewOrderStorage(dbClient)
-
-	broker := inmemory.NewBroker()
-
-	delay := delay.NewDelay()
-
-	webhookService := services.NewWebhookService(eventStorage, orderStorage, broker, delay)
-	orderService := services.NewOrderService(orderStorage)
-
-	handlers := handlers.NewHandler(webhookService, orderService)
-
-	server := api.NewHttpServer(8080, handlers.GetHandlers())
-
-	err = server.Serve()
+	core, err := a.newCore()
 	if err != nil {
-		log.Fatalf(fmt.Sprintf("failed to serve, err: %s", err))
+		fatal("failed to create services", err)
+	}
+	storage, broker, delay := core.storage, core.broker, core.delay
+	if storage.migrator == nil {
+		slog.Warn("use in-memory storage, data will be lost after restart")
+	}
+	// servers share lock, commands which change orders don't run with them
+	releaseLock := func() error { return nil }
+	if storage.db != nil {
+		release, ok, err := storage.db.TryLock(context.Background(), true)
+		if err != nil {
+			fatal("failed to lock db", err)
+		}
+		if !ok {
+			fatal("failed to lock db", errors.New("command which changes orders is running"))
+		}
+		releaseLock = release
 	}
 
-	exit := make(chan os.Signal, 1)
-	signal.Notify(exit, syscall.SIGINT, syscall.SIGTERM)
-
-	// shout down logic
-	<-exit
-
-	broker.Close()
+	archiveCtx, stopArchive := context.WithCancel(context.Background())
+	archiveDone := make(chan struct{})
+	if a.Config.Archive.Retention > 0 {
+		archiver := archive.NewArchiver(storage.events, storage.archives, core.blobStore, a.Config.Archive.Retention, a.Config.Archive.BatchSize)
+		go func() {
+			defer close(archiveDone)
+			archiver.Run(archiveCtx, a.Config.Archive.Interval)
+		}()
+	} else {
+		close(archiveDone)
+	}
 
-	err = server.Shutdown(context.Background())
+	webhookService := core.webhook
+	err = webhookService.ScheduleTimeouts(context.Background())
+	if err != nil {
+		fatal("failed to schedule timeouts", err)
+	}
+	err = webhookService.ScheduleCooldowns(context.Background())
 	if err != nil {
-		log.Printf("failed to stop server, err: %s", err)
+		fatal("failed to schedule cooldowns", err)
 	}
 
-	<-delay.GracefulExit()
+	var authenticator *auth.Authenticator
+	if a.Config.Auth.Enabled() {
+		authenticator, err = auth.NewAuthenticator(&a.Config.Auth)
+		if err != nil {
+			fatal("failed to create authenticator", err)
+		}
+	} else {
+		slog.Warn("auth is disabled, set api keys or jwks file to enable it")
+	}
 
-	err = dbClient.Close()
+	secret := []byte(a.Config.Stream.TokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			fatal("failed to generate stream token secret", err)
+		}
+		slog.Warn("stream token secret isn't set, stream tokens will be invalid after restart")
+	}
+	streamTokens := services.NewStreamTokenService(storage.orders, secret, a.Config.Stream.TokenTTL, a.Config.Stream.TokenMaxTTL, core.timeouts)
+
+	metrics.RegisterBroker(broker.SubscribersByTopic)
+	metrics.RegisterDelay(delay.Pending)
+
+	checker := health.NewChecker(a.Config.Timeouts.Health)
+	if storage.db != nil {
+		checker.AddCheck("db", storage.db.Ping)
+		checker.AddInfo("db_pool", func() any {
+			stats := storage.db.Stats()
+			return map[string]any{
+				"max_open_connections": stats.MaxOpenConnections,
+				"open_connections":     stats.OpenConnections,
+				"in_use":               stats.InUse,
+				"idle":                 stats.Idle,
+				"wait_count":           stats.WaitCount,
+				"wait_duration":        stats.WaitDuration.String(),
+			}
+		})
+	}
+	checker.AddCheck("broker", func(ctx context.Context) error {
+		if broker.Closed() {
+			return errors.New("broker is closed")
+		}
+		return nil
+	})
+	checker.AddCheck("scheduler", func(ctx context.Context) error {
+		if !delay.Running() {
+			return errors.New("scheduler is stopped")
+		}
+		return nil
+	})
+	checker.AddInfo("broker_subscribers", func() any { return broker.Subscribers() })
+	checker.AddInfo("pending_jobs", func() any { return delay.Pending() })
+
+	handlers := handlers.NewHandler(webhookService, core.order, core.stats, handlers.Options{
+		WebhookAuth: handlers.ClientAuth{
+			Required: a.Config.Server.TLS.ClientCAFile != "",
+			Allowed:  a.Config.Server.TLS.WebhookClients,
+		},
+		Auth:         authenticator,
+		CORSOrigins:  a.Config.Server.CORSOrigins,
+		StreamTokens: streamTokens,
+		Limits: handlers.Limits{
+			Webhook:        ratelimit.Limit{Rate: a.Config.RateLimit.Webhook.Rate, Burst: a.Config.RateLimit.Webhook.Burst},
+			Read:           ratelimit.Limit{Rate: a.Config.RateLimit.Read.Rate, Burst: a.Config.RateLimit.Read.Burst},
+			StreamsPerUser: a.Config.RateLimit.StreamsPerUser,
+			Streams:        a.Config.RateLimit.Streams,
+		},
+		Health: checker,
+	})
+
+	server := api.NewHttpServer(&a.Config.Server, handlers.GetHandlers())
+
+	// stages are run in order on shutdown, in-flight webhooks are saved before broker, scheduler and db are stopped
+	lc := lifecycle.New(a.Config.Server.ShutdownTimeout)
+	lc.OnShutdown("readiness", func(ctx context.Context) error {
+		// orchestrator stops sending requests
+		checker.Shutdown()
+		return nil
+	})
+	lc.OnShutdown("streams", func(ctx context.Context) error {
+		handlers.Drain()
+		return nil
+	})
+	lc.OnShutdown("http", func(ctx context.Context) error {
+		err := server.Shutdown(ctx)
+		if err != nil {
+			// connections of requests which didn't finish in time are closed
+			return errors.Join(err, server.Close())
+		}
+		return nil
+	})
+	lc.OnShutdown("broker", func(ctx context.Context) error {
+		broker.Close()
+		return nil
+	})
+	lc.OnShutdown("scheduler", func(ctx context.Context) error {
+		// pending jobs aren't run, they are restored from db on start
+		return lifecycle.Await(ctx, delay.Stop())
+	})
+	lc.OnShutdown("archive", func(ctx context.Context) error {
+		stopArchive()
+		return lifecycle.Await(ctx, archiveDone)
+	})
+	lc.OnShutdown("tracing", shutdownTracing)
+	lc.OnShutdown("db", func(ctx context.Context) error {
+		return errors.Join(releaseLock(), storage.close())
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err = lc.Wait(ctx, server.Serve)
 	if err != nil {
-		log.Printf("failed to close db connection, err: %s", err)
+		fatal("failed to shutdown gracefully", err)
 	}
+	slog.Info("app stopped")
+}
+
+// fatal logs error and exits, deferred functions aren't run
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"webhooker/internal/auth"
+)
+
+var errConfigUsage = errors.New("usage: config print | validate")
+
+// ConfigCmd runs config subcommand: print shows effective config with redacted secrets,
+// validate checks parts of config which are loaded only on start, e.g. status timeouts and jwks file
+func (a *App) ConfigCmd(args []string) error {
+	if len(args) == 0 {
+		return errConfigUsage
+	}
+
+	switch args[0] {
+	case "print":
+		return a.Config.Print(os.Stdout)
+	case "validate":
+		// fields of config are validated by load, so invalid config doesn't reach this point
+		if _, err := a.statusTimeouts(); err != nil {
+			return err
+		}
+		if a.Config.Auth.Enabled() {
+			if _, err := auth.NewAuthenticator(&a.Config.Auth); err != nil {
+				return fmt.Errorf("invalid auth config, err: %w", err)
+			}
+		}
+		fmt.Println("config is valid")
+		return nil
+	default:
+		return errConfigUsage
+	}
+}
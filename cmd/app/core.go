@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+	"webhooker/config"
+	"webhooker/internal/lifecycle"
+	"webhooker/internal/queue/inmemory"
+	"webhooker/internal/schedule/delay"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/archive"
+	"webhooker/internal/storage/blob"
+	"webhooker/internal/storage/instrumented"
+
+	storageApi "webhooker/internal/storage/api"
+)
+
+// core is storage and services which are shared by server and commands
+type core struct {
+	storage   *storage
+	blobStore storageApi.BlobStore
+	broker    *inmemory.Broker
+	delay     *delay.Delay
+	timeouts  services.Timeouts
+	webhook   *services.WebhookService
+	order     *services.OrderService
+	stats     *services.StatsService
+}
+
+func (a *App) newCore() (*core, error) {
+	statusTimeouts, err := a.statusTimeouts()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config, err: %w", err)
+	}
+
+	storage, err := a.newStorage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage, err: %w", err)
+	}
+	if storage.migrator != nil {
+		err = storage.migrator.Check()
+		if err != nil {
+			storage.close()
+			return nil, fmt.Errorf("failed to check db schema, run `migrate up` first, err: %w", err)
+		}
+	}
+
+	storage.orders = instrumented.NewOrderStorage(storage.orders, a.Config.Driver)
+	storage.events = instrumented.NewEventStorage(storage.events, a.Config.Driver)
+	storage.archives = instrumented.NewArchiveStorage(storage.archives, a.Config.Driver)
+	storage.stats = instrumented.NewStatsStorage(storage.stats, a.Config.Driver)
+
+	blobStore, err := blob.NewLocalStore(a.Config.Archive.Dir)
+	if err != nil {
+		storage.close()
+		return nil, fmt.Errorf("failed to create archive store, err: %w", err)
+	}
+	// events are always read through archive, so archived history stays available when archiving is disabled later
+	events := archive.NewEventStorage(storage.events, storage.archives, blobStore)
+
+	broker := inmemory.NewBroker(a.Config.Broker.Buffer)
+	delay := delay.NewDelay()
+
+	timeouts := services.Timeouts{
+		GetOrders: a.Config.Timeouts.GetOrders,
+		SaveEvent: a.Config.Timeouts.SaveEvent,
+		GetEvents: a.Config.Timeouts.GetEvents,
+		Job:       a.Config.Timeouts.Job,
+	}
+	settings := services.Settings{
+		Cooldown:   a.Config.Scheduler.Cooldown,
+		StreamWait: a.Config.Stream.WaitTime,
+	}
+
+	return &core{
+		storage:   storage,
+		blobStore: blobStore,
+		broker:    broker,
+		delay:     delay,
+		timeouts:  timeouts,
+		webhook:   services.NewWebhookService(events, storage.orders, broker, delay, statusTimeouts, timeouts, settings),
+		order:     services.NewOrderService(storage.orders, events, timeouts),
+		stats:     services.NewStatsService(storage.stats, timeouts),
+	}, nil
+}
+
+// statusTimeouts converts and validates status timeouts of config
+func (a *App) statusTimeouts() (map[string]models.StatusTimeout, error) {
+	timeouts := make(map[string]models.StatusTimeout, len(a.Config.StatusTimeouts))
+	for _, t := range a.Config.StatusTimeouts {
+		timeouts[t.Status] = models.StatusTimeout{
+			Timeout:  t.Timeout,
+			ToStatus: t.ToStatus,
+		}
+	}
+	return timeouts, services.ValidateTimeouts(timeouts)
+}
+
+// close is used by commands, server stops core by shutdown stages.
+// Pending jobs are canceled, server restores them from storage on start
+func (c *core) close(timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	defer cancel()
+
+	c.broker.Close()
+	stopErr := lifecycle.Await(ctx, c.delay.Stop())
+	return errors.Join(stopErr, c.storage.close())
+}
+
+// errServerRunning is returned by commands which change orders while server is running
+var errServerRunning = errors.New("server is running, stop it before command: server doesn't get events and jobs of command")
+
+// runStopped runs command which changes orders, server should be stopped: events published by command
+// don't reach streams of server, and cooldowns and timeouts of command are scheduled only by server on start
+func (a *App) runStopped(run func(ctx context.Context, c *core) error) error {
+	return a.runCommand(func(ctx context.Context, c *core) error {
+		release, err := lockStopped(ctx, c.storage.db)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return run(ctx, c)
+	})
+}
+
+// lockStopped takes exclusive lock of db, it fails with errServerRunning while any server holds shared lock
+func lockStopped(ctx context.Context, db dbClient) (release func() error, err error) {
+	release, ok, err := db.TryLock(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock db, err: %w", err)
+	}
+	if !ok {
+		return nil, errServerRunning
+	}
+	return release, nil
+}
+
+// runCommand creates core for command and closes it after command, command is canceled by interrupt
+func (a *App) runCommand(run func(ctx context.Context, c *core) error) (err error) {
+	if a.Config.Driver == config.DriverMemory {
+		return errors.New("in-memory storage is empty in new process, use sqlite or postgres driver")
+	}
+	c, err := a.newCore()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, c.close(a.Config.Server.ShutdownTimeout))
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return run(ctx, c)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"webhooker/api/handlers"
+)
+
+var (
+	errFinalizeUsage = errors.New("usage: finalize [-o table|json] <order_id>")
+	errCooldownUsage = errors.New("usage: cooldown cancel [-o table|json] <order_id>")
+)
+
+// Finalize runs finalize subcommand: makes order final in its current status,
+// cooldown and status timeout of order aren't waited
+func (a *App) Finalize(args []string) error {
+	p, args, err := commandFlags("finalize", args, nil)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errFinalizeUsage
+	}
+	return a.runStopped(func(ctx context.Context, c *core) error {
+		order, err := c.webhook.Finalize(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to finalize order, err: %w", err)
+		}
+		return p.print(handlers.OrderToOrderResp(order), func(w io.Writer) { printOrder(w, order) })
+	})
+}
+
+// Cooldown runs cooldown subcommand: cancel ends cooldown of done order, so order is final without refund
+func (a *App) Cooldown(args []string) error {
+	if len(args) == 0 || args[0] != "cancel" {
+		return errCooldownUsage
+	}
+	p, args, err := commandFlags("cooldown cancel", args[1:], nil)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errCooldownUsage
+	}
+	return a.runStopped(func(ctx context.Context, c *core) error {
+		order, err := c.webhook.CancelCooldown(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to cancel cooldown, err: %w", err)
+		}
+		return p.print(handlers.OrderToOrderResp(order), func(w io.Writer) { printOrder(w, order) })
+	})
+}
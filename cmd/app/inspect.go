@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"webhooker/api/handlers"
+	"webhooker/internal/services/models"
+)
+
+var errInspectUsage = errors.New("usage: inspect [-o table|json] <order_id>")
+
+// Inspect runs inspect subcommand: prints order with its events and time in every status
+func (a *App) Inspect(args []string) error {
+	p, args, err := commandFlags("inspect", args, nil)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errInspectUsage
+	}
+
+	return a.runCommand(func(ctx context.Context, c *core) error {
+		timeline, err := c.order.GetTimeline(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get order, err: %w", err)
+		}
+		return p.print(handlers.TimelineToTimelineResp(timeline), func(w io.Writer) {
+			printOrder(w, timeline.Order)
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "EVENT\tSTATUS\tFINAL\tSYSTEM\tSTREAMED\tUPDATED AT\tRECEIVED AT\tTIME IN STATUS")
+			for _, te := range timeline.Events {
+				e := te.Event
+				receivedAt, timeInStatus := "-", "-"
+				if !e.ReceivedAt.IsZero() {
+					receivedAt = e.ReceivedAt.Format(timeLayout)
+				}
+				if te.TimeInStatus != nil {
+					timeInStatus = te.TimeInStatus.String()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%t\t%s\t%s\t%s\n", e.EventID, e.OrderStatus, e.IsFinal, e.IsSystem, te.IsStreamed,
+					e.UpdateAt.Format(timeLayout), receivedAt, timeInStatus)
+			}
+		})
+	})
+}
+
+// printOrder writes order as key value table
+func printOrder(w io.Writer, order *models.Order) {
+	fmt.Fprintf(w, "ORDER\t%s\n", order.ID)
+	fmt.Fprintf(w, "USER\t%s\n", order.UserID)
+	fmt.Fprintf(w, "STATUS\t%s\n", order.Status)
+	fmt.Fprintf(w, "FINAL\t%t\n", order.IsFinal)
+	fmt.Fprintf(w, "CREATED AT\t%s\n", order.CreateAt.Format(timeLayout))
+	fmt.Fprintf(w, "UPDATED AT\t%s\n", order.UpdateAt.Format(timeLayout))
+	fmt.Fprintf(w, "VERSION\t%d\n", order.Version)
+}
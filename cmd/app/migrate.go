@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+	"webhooker/internal/storage/migrate"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | down [steps] | status [-o table|json] | seed")
+
+// migrationStatus is migration in output of migrate status, applied_at is absent for pending migration
+type migrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrate runs migrate subcommand: up, down [steps], status or seed.
+// Subcommands which change db require stopped server, status can run with server
+func (a *App) Migrate(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	var p *printer
+	switch args[0] {
+	case "status":
+		var err error
+		p, args, err = commandFlags("migrate status", args[1:], nil)
+		if err != nil {
+			return err
+		}
+		if len(args) != 0 {
+			return errMigrateUsage
+		}
+	case "up", "down", "seed":
+	default:
+		return errMigrateUsage
+	}
+
+	storage, err := a.newStorage()
+	if err != nil {
+		return err
+	}
+	defer storage.close()
+
+	migrator := storage.migrator
+	if migrator == nil {
+		return fmt.Errorf("migrations aren't supported by %s storage", a.Config.Driver)
+	}
+
+	if p != nil {
+		return printMigrations(p, migrator)
+	}
+
+	release, err := lockStopped(context.Background(), storage.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up()
+		slog.Info("applied migrations", slog.Int("count", count))
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %s", args[1])
+			}
+		}
+		count, err := migrator.Down(steps)
+		slog.Info("reverted migrations", slog.Int("count", count))
+		return err
+	default:
+		err := migrator.Seed()
+		if err == nil {
+			slog.Info("test data inserted")
+		}
+		return err
+	}
+}
+
+func printMigrations(p *printer, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	result := make([]migrationStatus, 0, len(statuses))
+	for _, s := range statuses {
+		result = append(result, migrationStatus{Version: s.Version, Name: s.Name, AppliedAt: s.AppliedAt})
+	}
+	return p.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range result {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(timeLayout)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes result of command as table for operators or as json for scripts
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// commandFlags parses flags of command, every command has -o flag
+func commandFlags(name string, args []string, define func(flags *flag.FlagSet)) (*printer, []string, error) {
+	p := &printer{w: os.Stdout}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&p.format, "o", outputTable, "output format, table or json")
+	if define != nil {
+		define(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if p.format != outputTable && p.format != outputJSON {
+		return nil, nil, fmt.Errorf("unsupported output %s, use table or json", p.format)
+	}
+	return p, flags.Args(), nil
+}
+
+// print writes v as json or calls table with writer which aligns tab separated columns
+func (p *printer) print(v any, table func(w io.Writer)) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"webhooker/api/handlers"
+	"webhooker/internal/metrics"
+	"webhooker/internal/services/models"
+)
+
+// maxReplayLine is size of the longest line of replay file
+const maxReplayLine = 1 << 20
+
+var errReplayUsage = errors.New("usage: replay [-o table|json] <file.ndjson | ->")
+
+// replayResult counts events by outcome, outcomes are the same as outcomes of webhooks metric
+type replayResult struct {
+	Outcomes map[string]int `json:"outcomes"`
+	// Failed are lines which weren't saved, duplicates and events after final status aren't failures
+	Failed []replayFailure `json:"failed"`
+}
+
+type replayFailure struct {
+	Line    int    `json:"line"`
+	EventID string `json:"event_id,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error"`
+}
+
+// Replay runs replay subcommand: saves webhook events from ndjson file in order of lines,
+// every line is body of webhook. Server should be stopped, it schedules cooldowns and timeouts of replayed orders on start
+func (a *App) Replay(args []string) error {
+	p, args, err := commandFlags("replay", args, nil)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errReplayUsage
+	}
+
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open replay file, err: %w", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	return a.runStopped(func(ctx context.Context, c *core) error {
+		result, err := replay(ctx, c, in)
+		if err != nil {
+			return err
+		}
+		err = p.print(result, func(w io.Writer) {
+			fmt.Fprintln(w, "OUTCOME\tEVENTS")
+			for _, outcome := range []string{metrics.OutcomeAccepted, metrics.OutcomeDuplicate, metrics.OutcomeAfterFinal,
+				metrics.OutcomeConflict, metrics.OutcomeInvalid, metrics.OutcomeError} {
+				fmt.Fprintf(w, "%s\t%d\n", outcome, result.Outcomes[outcome])
+			}
+			if len(result.Failed) > 0 {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, "LINE\tEVENT\tOUTCOME\tERROR")
+				for _, f := range result.Failed {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", f.Line, f.EventID, f.Outcome, f.Error)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d events weren't replayed", len(result.Failed))
+		}
+		return nil
+	})
+}
+
+func replay(ctx context.Context, c *core, in io.Reader) (*replayResult, error) {
+	result := &replayResult{Outcomes: make(map[string]int), Failed: []replayFailure{}}
+	fail := func(line int, eventID string, outcome string, err error) {
+		result.Outcomes[outcome]++
+		result.Failed = append(result.Failed, replayFailure{Line: line, EventID: eventID, Outcome: outcome, Error: err.Error()})
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxReplayLine)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var webhook handlers.WebhookEvent
+		if err := json.Unmarshal([]byte(text), &webhook); err != nil {
+			fail(line, "", metrics.OutcomeInvalid, err)
+			continue
+		}
+		event, err := handlers.WebhookToEvent(&webhook)
+		if err != nil {
+			fail(line, webhook.EventID, metrics.OutcomeInvalid, err)
+			continue
+		}
+		if _, ok := models.StatusPriority[event.OrderStatus]; !ok {
+			fail(line, event.EventID, metrics.OutcomeInvalid, fmt.Errorf("unsupported status %s", event.OrderStatus))
+			continue
+		}
+		event.ReceivedAt = time.Now().UTC()
+
+		err = c.webhook.SaveEvent(ctx, event)
+		switch {
+		case err == nil:
+			result.Outcomes[metrics.OutcomeAccepted]++
+		case errors.Is(err, models.ErrAlreadyExist):
+			result.Outcomes[metrics.OutcomeDuplicate]++
+		case errors.Is(err, models.ErrAfterFinal):
+			result.Outcomes[metrics.OutcomeAfterFinal]++
+		case errors.Is(err, models.ErrVersionConflict):
+			fail(line, event.EventID, metrics.OutcomeConflict, err)
+		default:
+			fail(line, event.EventID, metrics.OutcomeError, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file, err: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"webhooker/internal/metrics"
+	"webhooker/internal/queue/inmemory"
+	"webhooker/internal/schedule/delay"
+	"webhooker/internal/services"
+	"webhooker/internal/services/models"
+
+	memstorage "webhooker/internal/storage/inmemory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func webhookLine(eventID string, orderID string, status string, updatedAt string) string {
+	return fmt.Sprintf(`{"event_id":%q,"order_id":%q,"user_id":"user1","order_status":%q,"created_at":"2024-01-01T10:00:00Z","updated_at":%q}`,
+		eventID, orderID, status, updatedAt)
+}
+
+func Test_Replay(t *testing.T) {
+	d := delay.NewDelay()
+	defer func() { <-d.Stop() }()
+	c := &core{
+		webhook: services.NewWebhookService(&memstorage.EventStorage{}, &memstorage.OrderStorage{}, inmemory.NewBroker(1), d,
+			nil, services.Timeouts{}, services.Settings{}),
+	}
+
+	in := strings.Join([]string{
+		webhookLine("e1", "order1", models.OrderCreatedStatus, "2024-01-01T10:00:00Z"),
+		webhookLine("e1", "order1", models.OrderCreatedStatus, "2024-01-01T10:00:00Z"),
+		"",
+		`{"event_id":`,
+		webhookLine("e2", "order1", models.PendingStatus, "yesterday"),
+		webhookLine("e3", "order1", "unknown", "2024-01-01T10:01:00Z"),
+		webhookLine("e4", "order1", models.FailedStatus, "2024-01-01T10:02:00Z"),
+		webhookLine("e5", "order1", models.ConfirmedStatus, "2024-01-01T10:03:00Z"),
+		"  ",
+	}, "\n")
+
+	result, err := replay(context.Background(), c, strings.NewReader(in))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{
+		metrics.OutcomeAccepted:   2,
+		metrics.OutcomeDuplicate:  1,
+		metrics.OutcomeInvalid:    3,
+		metrics.OutcomeAfterFinal: 1,
+	}, result.Outcomes)
+
+	// lines are counted from 1 with empty lines
+	var failed []string
+	for _, f := range result.Failed {
+		failed = append(failed, fmt.Sprintf("%d %s %s", f.Line, f.EventID, f.Outcome))
+	}
+	assert.Equal(t, []string{"4  invalid", "5 e2 invalid", "6 e3 invalid"}, failed)
+}
+
+func Test_Replay_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := replay(ctx, &core{}, strings.NewReader(webhookLine("e1", "order1", models.OrderCreatedStatus, "2024-01-01T10:00:00Z")))
+	assert.ErrorIs(t, err, context.Canceled)
+}
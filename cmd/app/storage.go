@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"webhooker/config"
+	"webhooker/internal/storage/migrate"
+	"webhooker/internal/storage/posgres"
+	"webhooker/internal/storage/sqlite"
+
+	storageApi "webhooker/internal/storage/api"
+	memstorage "webhooker/internal/storage/inmemory"
+)
+
+type storage struct {
+	events storageApi.EventStorage
+	orders storageApi.OrderStorage
+	// archives share db with events, archiver removes archived events
+	archives storageApi.ArchiveStorage
+	stats    storageApi.StatsStorage
+	// migrator is nil for in-memory storage
+	migrator *migrate.Migrator
+	// db is nil for in-memory storage
+	db    dbClient
+	close func() error
+}
+
+// dbClient is checked by readiness probe, its lock keeps commands which change orders from running with server
+type dbClient interface {
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+	TryLock(ctx context.Context, shared bool) (release func() error, ok bool, err error)
+}
+
+func (a *App) newStorage() (*storage, error) {
+	switch a.Config.Driver {
+	case config.DriverMemory:
+		orders, events := &memstorage.OrderStorage{}, &memstorage.EventStorage{}
+		return &storage{
+			events:   events,
+			orders:   orders,
+			archives: memstorage.NewArchiveStorage(orders, events),
+			stats:    memstorage.NewStatsStorage(orders, events),
+			close:    func() error { return nil },
+		}, nil
+	case config.DriverSqlite:
+		dbClient, err := sqlite.NewSqliteClient(&a.Config.Sqlite)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create db client %w", err)
+		}
+		migrator, err := sqlite.NewMigrator(dbClient)
+		if err != nil {
+			dbClient.Close()
+			return nil, fmt.Errorf("failed to read migrations, err: %w", err)
+		}
+		return &storage{
+			events:   sqlite.NewEventStorage(dbClient),
+			orders:   sqlite.NewOrderStorage(dbClient),
+			archives: sqlite.NewArchiveStorage(dbClient),
+			stats:    sqlite.NewStatsStorage(dbClient),
+			migrator: migrator,
+			db:       dbClient,
+			close:    dbClient.Close,
+		}, nil
+	default:
+		dbClient, err := posgres.NewPgClient(&a.Config.Postgress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create db client %w", err)
+		}
+		migrator, err := posgres.NewMigrator(dbClient)
+		if err != nil {
+			dbClient.Close()
+			return nil, fmt.Errorf("failed to read migrations, err: %w", err)
+		}
+		return &storage{
+			events:   posgres.NewEventStorage(dbClient),
+			orders:   posgres.NewOrderStorage(dbClient),
+			archives: posgres.NewArchiveStorage(dbClient),
+			stats:    posgres.NewStatsStorage(dbClient),
+			migrator: migrator,
+			db:       dbClient,
+			close:    dbClient.Close,
+		}, nil
+	}
+}
@@ -1,20 +1,60 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
 	"webhooker/config"
+	"webhooker/internal/logging"
 
 	"webhooker/cmd/app"
 )
 
 func main() {
-	c, err := config.GetConfig()
+	c, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal("failed to get config")
+		log.Fatalf("failed to get config, err: %s", err)
 	}
+	logging.Setup(&c.Log)
 	app := app.App{
 		Config: c,
 	}
 
-	app.Run()
+	if len(args) == 0 || args[0] == "serve" {
+		app.Run()
+		return
+	}
+
+	// commands wire the same services as server, they are run with the same config
+	commands := map[string]func(args []string) error{
+		"migrate":  app.Migrate,
+		"config":   app.ConfigCmd,
+		"replay":   app.Replay,
+		"inspect":  app.Inspect,
+		"finalize": app.Finalize,
+		"cooldown": app.Cooldown,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fatal("unknown command, use serve, migrate, config, replay, inspect, finalize or cooldown", fmt.Errorf("command %s", args[0]))
+	}
+	err = command(args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal(fmt.Sprintf("failed to run %s command", args[0]), err)
+	}
+}
+
+// fatal logs error with configured logger and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
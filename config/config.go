@@ -1,45 +1,412 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+	DriverMemory   = "memory"
+
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+
+	LogText = "text"
+	LogJSON = "json"
+
+	defaultAddr              = ":8080"
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultShutdownTimeout   = 30 * time.Second
+
+	defaultSqlitePath = "./tmp/webhooker.db"
+	defaultSSLMode    = "disable"
+
+	defaultGetOrdersTimeout = 5 * time.Second
+	defaultSaveEventTimeout = 5 * time.Second
+	defaultGetEventsTimeout = 5 * time.Second
+	defaultJobTimeout       = 10 * time.Second
+	defaultHealthTimeout    = 2 * time.Second
+
+	defaultArchiveDir       = "./tmp/archive"
+	defaultArchiveInterval  = time.Hour
+	defaultArchiveBatchSize = 100
+
+	defaultCooldown          = 30 * time.Second
+	defaultStreamWaitTime    = 60 * time.Second
+	defaultStreamTokenTTL    = 5 * time.Minute
+	defaultStreamTokenMaxTTL = time.Hour
+
+	defaultUserClaim = "sub"
+	// api keys are compared as secrets, short keys can be guessed
+	minAPIKeyLength = 16
+	// stream tokens are signed by hmac-sha256
+	minStreamTokenSecretLength = 32
+
+	defaultWebhookRate    = 50
+	defaultWebhookBurst   = 100
+	defaultReadRate       = 10
+	defaultReadBurst      = 20
+	defaultStreamsPerUser = 5
+	defaultStreams        = 1000
+
+	// env with path to yaml config, -config flag has priority
+	configFileEnv = "CONFIG_FILE"
 )
 
 type Config struct {
-	Postgress PgCredentials
+	Driver         string          `yaml:"driver"`
+	Server         ServerConfig    `yaml:"server"`
+	Postgress      PgCredentials   `yaml:"postgres"`
+	Sqlite         SqliteConfig    `yaml:"sqlite"`
+	StatusTimeouts []StatusTimeout `yaml:"status_timeouts"`
+	Timeouts       Timeouts        `yaml:"timeouts"`
+	Archive        ArchiveConfig   `yaml:"archive"`
+	Broker         BrokerConfig    `yaml:"broker"`
+	Scheduler      SchedulerConfig `yaml:"scheduler"`
+	Stream         StreamConfig    `yaml:"stream"`
+	Auth           AuthConfig      `yaml:"auth"`
+	RateLimit      RateLimitConfig `yaml:"rate_limit"`
+	Tracing        TracingConfig   `yaml:"tracing"`
+	Log            LogConfig       `yaml:"log"`
+}
+
+// ServerConfig of http server, 0 timeout disables it
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	// streams and exports are long responses, keep it 0 or large enough
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is deadline of whole graceful shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	TLS             TLSConfig     `yaml:"tls"`
+	// origins allowed to read events stream from browser
+	CORSOrigins []string `yaml:"cors_origins"`
+}
+
+// TLSConfig enables https if both files are set, files are reloaded on change.
+// ClientCAFile enables verification of client certificates, it is required only for webhook route
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	// identities (certificate common name) of payment providers allowed to send webhooks, empty allows any verified client
+	WebhookClients []string `yaml:"webhook_clients"`
 }
 
 type PgCredentials struct {
-	User     string
-	Password string
-	Host     string
-	DbName   string
+	User     string `yaml:"user"`
+	Password string `yaml:"password" secret:"true"`
+	Host     string `yaml:"host"`
+	DbName   string `yaml:"db_name"`
+	SSLMode  string `yaml:"ssl_mode"`
+	// pool settings, 0 keeps database/sql defaults
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 }
 
-func GetConfig() (*Config, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
+type SqliteConfig struct {
+	Path string `yaml:"path"`
+}
 
-	user := os.Getenv("PG_USER")
-	pass := os.Getenv("PG_PASS")
-	host := os.Getenv("PG_HOST")
-	dbName := os.Getenv("PG_DB_NAME")
+// Timeouts of operations, 0 disables timeout
+type Timeouts struct {
+	GetOrders time.Duration `yaml:"get_orders"`
+	SaveEvent time.Duration `yaml:"save_event"`
+	GetEvents time.Duration `yaml:"get_events"`
+	Job       time.Duration `yaml:"job"`
+	// Health is timeout of every readiness check
+	Health time.Duration `yaml:"health"`
+}
 
-	if user == "" && pass == "" && host == "" && dbName == "" {
-		return nil, fmt.Errorf("config fields is empty")
-	}
+// ArchiveConfig of events retention, events of orders final for longer than Retention are moved to Dir,
+// 0 Retention disables archiving
+type ArchiveConfig struct {
+	Retention time.Duration `yaml:"retention"`
+	Interval  time.Duration `yaml:"interval"`
+	Dir       string        `yaml:"dir"`
+	BatchSize int           `yaml:"batch_size"`
+}
+
+// BrokerConfig of in-memory broker, 0 Buffer makes publisher wait for every subscriber
+type BrokerConfig struct {
+	Buffer int `yaml:"buffer"`
+}
+
+// SchedulerConfig of delayed jobs, order is finalized after Cooldown since done status
+type SchedulerConfig struct {
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// StreamConfig of events stream, inactive stream is closed after WaitTime.
+// Stream tokens are signed by TokenSecret, random secret is generated if it isn't set
+type StreamConfig struct {
+	WaitTime    time.Duration `yaml:"wait_time"`
+	TokenSecret string        `yaml:"token_secret" secret:"true"`
+	TokenTTL    time.Duration `yaml:"token_ttl"`
+	TokenMaxTTL time.Duration `yaml:"token_max_ttl"`
+}
+
+// AuthConfig of bearer tokens: api keys of services and jwt of end users verified by keys from JWKSFile.
+// Auth is disabled if neither api keys nor jwks file is set
+type AuthConfig struct {
+	APIKeys  []APIKey `yaml:"api_keys"`
+	JWKSFile string   `yaml:"jwks_file"`
+	// Issuer and Audience are checked if set
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// UserClaim is claim with user id of end user
+	UserClaim string `yaml:"user_claim"`
+}
+
+func (a *AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.JWKSFile != ""
+}
+
+// APIKey of service, scopes: ingest, read, admin
+type APIKey struct {
+	Name   string   `yaml:"name"`
+	Key    string   `yaml:"key" secret:"true"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// RateLimitConfig of clients: api key, end user or ip. Zero rate or zero streams limit disables it
+type RateLimitConfig struct {
+	// Webhook limits webhook route, Read limits every read route
+	Webhook RateConfig `yaml:"webhook"`
+	Read    RateConfig `yaml:"read"`
+	// StreamsPerUser limits concurrent events streams of one client, Streams limits all of them
+	StreamsPerUser int `yaml:"streams_per_user"`
+	Streams        int `yaml:"streams"`
+}
+
+// RateConfig of token bucket, Rate requests per second with bursts up to Burst
+type RateConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// TracingConfig of opentelemetry spans exporter: none, stdout or otlp.
+// Endpoint is host:port of otlp http receiver, SampleRatio is part of traces started by service which are recorded
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// LogConfig of structured logs, level is debug, info, warn or error, format is text or json.
+// RedactUserIDs replaces user ids with their hashes, logs of the same user can still be correlated
+type LogConfig struct {
+	Level         string `yaml:"level"`
+	Format        string `yaml:"format"`
+	RedactUserIDs bool   `yaml:"redact_user_ids"`
+}
+
+type StatusTimeout struct {
+	Status   string        `yaml:"status"`
+	Timeout  time.Duration `yaml:"timeout"`
+	ToStatus string        `yaml:"to_status"`
+}
 
+func Default() *Config {
 	return &Config{
+		Driver: DriverPostgres,
+		Server: ServerConfig{
+			Addr:              defaultAddr,
+			ReadHeaderTimeout: defaultReadHeaderTimeout,
+			IdleTimeout:       defaultIdleTimeout,
+			ShutdownTimeout:   defaultShutdownTimeout,
+		},
 		Postgress: PgCredentials{
-			User:     user,
-			Password: pass,
-			Host:     host,
-			DbName:   dbName,
+			SSLMode: defaultSSLMode,
+		},
+		Sqlite: SqliteConfig{
+			Path: defaultSqlitePath,
+		},
+		Timeouts: Timeouts{
+			GetOrders: defaultGetOrdersTimeout,
+			SaveEvent: defaultSaveEventTimeout,
+			GetEvents: defaultGetEventsTimeout,
+			Job:       defaultJobTimeout,
+			Health:    defaultHealthTimeout,
+		},
+		Archive: ArchiveConfig{
+			Interval:  defaultArchiveInterval,
+			Dir:       defaultArchiveDir,
+			BatchSize: defaultArchiveBatchSize,
+		},
+		Scheduler: SchedulerConfig{
+			Cooldown: defaultCooldown,
+		},
+		Stream: StreamConfig{
+			WaitTime:    defaultStreamWaitTime,
+			TokenTTL:    defaultStreamTokenTTL,
+			TokenMaxTTL: defaultStreamTokenMaxTTL,
 		},
-	}, nil
+		Auth: AuthConfig{
+			UserClaim: defaultUserClaim,
+		},
+		RateLimit: RateLimitConfig{
+			Webhook:        RateConfig{Rate: defaultWebhookRate, Burst: defaultWebhookBurst},
+			Read:           RateConfig{Rate: defaultReadRate, Burst: defaultReadBurst},
+			StreamsPerUser: defaultStreamsPerUser,
+			Streams:        defaultStreams,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingNone,
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogText,
+		},
+	}
+}
+
+// Load builds config from layers: defaults, yaml file, env (.env is optional), command line flags.
+// Returns validated config and arguments left after flags, for example subcommand
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+	opts := settings(cfg)
+
+	type flagValue struct {
+		opt   setting
+		value string
+	}
+	var flagValues []flagValue
+	var path string
+
+	flags := flag.NewFlagSet("webhooker", flag.ContinueOnError)
+	flags.StringVar(&path, "config", "", "path to yaml config, env "+configFileEnv)
+	for _, opt := range opts {
+		opt := opt
+		// flags are applied after file and env, only remember them here
+		flags.Func(opt.flag, fmt.Sprintf("%s, env %s", opt.usage, opt.env), func(value string) error {
+			flagValues = append(flagValues, flagValue{opt: opt, value: value})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// .env is only for local run, env can be set in other way
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load .env file, err: %w", err)
+	}
+
+	if path == "" {
+		path = os.Getenv(configFileEnv)
+	}
+	if path != "" {
+		if err := readFile(path, cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		value := os.Getenv(opt.env)
+		if value == "" {
+			continue
+		}
+		if err := opt.set(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s %q, err: %w", opt.env, value, err)
+		}
+	}
+
+	for _, f := range flagValues {
+		if err := f.opt.set(f.value); err != nil {
+			return nil, nil, fmt.Errorf("invalid -%s %q, err: %w", f.opt.flag, f.value, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, flags.Args(), nil
+}
+
+func readFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file, err: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// typo in key shouldn't silently fall back to default
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s, err: %w", path, err)
+	}
+	return nil
+}
+
+// format: <name>:<key>:<scope>|<scope>,... for example warehouse:secret:read
+func parseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid api key of %q, expected <name>:<key>:<scopes>", parts[0])
+		}
+		keys = append(keys, APIKey{
+			Name:   parts[0],
+			Key:    parts[1],
+			Scopes: strings.Split(parts[2], "|"),
+		})
+	}
+	return keys, nil
+}
+
+// format: <status>=<timeout>:<to_status>,... for example sbu_verification_pending=24h:failed
+func parseStatusTimeouts(value string) ([]StatusTimeout, error) {
+	var timeouts []StatusTimeout
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return timeouts, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		status, rule, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid status timeout %q", item)
+		}
+		timeoutStr, toStatus, ok := strings.Cut(rule, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid status timeout %q", item)
+		}
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for status %s, err: %w", status, err)
+		}
+		timeouts = append(timeouts, StatusTimeout{
+			Status:   status,
+			Timeout:  timeout,
+			ToStatus: toStatus,
+		})
+	}
+	return timeouts, nil
 }
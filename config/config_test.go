@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Load_Layers(t *testing.T) {
+	path := writeConfig(t, `
+driver: sqlite
+server:
+  addr: ":9000"
+  write_timeout: 1m
+timeouts:
+  get_orders: 1s
+status_timeouts:
+  - status: sbu_verification_pending
+    timeout: 24h
+    to_status: failed
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("HTTP_ADDR", ":9001")
+	t.Setenv("TIMEOUT_GET_ORDERS", "2s")
+
+	cfg, args, err := Load([]string{"-timeout-get-orders", "3s", "migrate", "up"})
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	// file overrides defaults
+	assert.Equal(t, DriverSqlite, cfg.Driver)
+	assert.Equal(t, time.Minute, cfg.Server.WriteTimeout)
+	assert.Equal(t, []StatusTimeout{{Status: "sbu_verification_pending", Timeout: 24 * time.Hour, ToStatus: "failed"}}, cfg.StatusTimeouts)
+	// env overrides file
+	assert.Equal(t, ":9001", cfg.Server.Addr)
+	// flag overrides env
+	assert.Equal(t, 3*time.Second, cfg.Timeouts.GetOrders)
+	// defaults
+	assert.Equal(t, defaultSaveEventTimeout, cfg.Timeouts.SaveEvent)
+	assert.Equal(t, defaultCooldown, cfg.Scheduler.Cooldown)
+}
+
+func Test_Load_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		file   string
+		args   []string
+		expErr string
+	}{
+		{
+			name:   "unknown field in file",
+			file:   "driver: sqlite\nsevrer:\n  addr: \":9000\"\n",
+			expErr: "field sevrer not found",
+		},
+		{
+			name:   "invalid flag value",
+			file:   "driver: sqlite\n",
+			args:   []string{"-archive-interval", "hour"},
+			expErr: `invalid -archive-interval "hour"`,
+		},
+		{
+			name:   "postgres without credentials",
+			file:   "driver: postgres\n",
+			expErr: "postgres.host: required for postgres driver",
+		},
+		{
+			name:   "otlp exporter without endpoint",
+			file:   "driver: sqlite\ntracing:\n  exporter: otlp\n",
+			expErr: "tracing.endpoint: required for otlp exporter",
+		},
+		{
+			name:   "unsupported log level",
+			file:   "driver: sqlite\n",
+			args:   []string{"-log-level", "verbose"},
+			expErr: `log.level: unsupported level "verbose"`,
+		},
+		{
+			name:   "all problems are reported",
+			file:   "driver: sqlite\nserver:\n  addr: localhost\n  tls:\n    cert_file: cert.pem\nstream:\n  wait_time: 0s\n",
+			expErr: "invalid config:\nserver.addr: should be [host]:port, got \"localhost\"\nserver.tls: cert_file and key_file should be set together\nstream.wait_time: should be positive, got 0s",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", writeConfig(t, tc.file))
+			t.Setenv("PG_HOST", "")
+
+			_, _, err := Load(tc.args)
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func Test_Print(t *testing.T) {
+	cfg := Default()
+	cfg.Postgress.Password = "pg-password"
+
+	var out bytes.Buffer
+	require.Nil(t, cfg.Print(&out))
+
+	assert.NotContains(t, out.String(), "pg-password")
+	assert.Contains(t, out.String(), "password: <redacted>")
+	assert.Contains(t, out.String(), "get_orders: 5s")
+
+	// printed config can be loaded back
+	t.Setenv("CONFIG_FILE", writeConfig(t, out.String()))
+	t.Setenv("STORAGE_DRIVER", DriverMemory)
+	loaded, _, err := Load(nil)
+	require.Nil(t, err)
+	assert.Equal(t, cfg.Timeouts, loaded.Timeouts)
+	assert.Equal(t, cfg.Server.Addr, loaded.Server.Addr)
+	assert.Equal(t, cfg.Server.IdleTimeout, loaded.Server.IdleTimeout)
+	assert.Empty(t, loaded.Server.TLS.WebhookClients)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "<redacted>"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Print writes effective config as yaml, which can be used as config file.
+// Fields with `secret:"true"` tag are redacted
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(toNode(reflect.ValueOf(*c))); err != nil {
+		return fmt.Errorf("failed to print config, err: %w", err)
+	}
+	return encoder.Close()
+}
+
+// toNode keeps order of fields and writes durations as strings, yaml.Marshal writes them as nanoseconds
+func toNode(v reflect.Value) *yaml.Node {
+	if v.Type() == durationType {
+		return scalar("!!str", time.Duration(v.Int()).String())
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			value := toNode(v.Field(i))
+			if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				value = scalar("!!str", redacted)
+			}
+			node.Content = append(node.Content, scalar("!!str", field.Tag.Get("yaml")), value)
+		}
+		return node
+	case reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			node.Content = append(node.Content, toNode(v.Index(i)))
+		}
+		return node
+	case reflect.Int:
+		return scalar("!!int", strconv.FormatInt(v.Int(), 10))
+	case reflect.Float64:
+		return scalar("!!float", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Bool:
+		return scalar("!!bool", strconv.FormatBool(v.Bool()))
+	default:
+		return scalar("!!str", v.String())
+	}
+}
+
+func scalar(tag string, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting can be set by env and command line flag
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(string) error
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{"driver", "STORAGE_DRIVER", "storage driver: postgres, sqlite or memory", setString(&c.Driver)},
+
+		{"addr", "HTTP_ADDR", "http server address", setString(&c.Server.Addr)},
+		{"read-header-timeout", "HTTP_READ_HEADER_TIMEOUT", "timeout to read request headers", setDuration(&c.Server.ReadHeaderTimeout)},
+		{"read-timeout", "HTTP_READ_TIMEOUT", "timeout to read request", setDuration(&c.Server.ReadTimeout)},
+		{"write-timeout", "HTTP_WRITE_TIMEOUT", "timeout to write response, limits streams and exports", setDuration(&c.Server.WriteTimeout)},
+		{"idle-timeout", "HTTP_IDLE_TIMEOUT", "keep-alive timeout", setDuration(&c.Server.IdleTimeout)},
+		{"shutdown-timeout", "HTTP_SHUTDOWN_TIMEOUT", "deadline of graceful shutdown: requests, streams, jobs and db, 0 waits without limit", setDuration(&c.Server.ShutdownTimeout)},
+		{"cors-origins", "HTTP_CORS_ORIGINS", "comma separated origins allowed to read events stream", setList(&c.Server.CORSOrigins)},
+		{"tls-cert", "TLS_CERT_FILE", "tls certificate file", setString(&c.Server.TLS.CertFile)},
+		{"tls-key", "TLS_KEY_FILE", "tls private key file", setString(&c.Server.TLS.KeyFile)},
+		{"tls-client-ca", "TLS_CLIENT_CA_FILE", "ca of client certificates for webhook route", setString(&c.Server.TLS.ClientCAFile)},
+		{"tls-webhook-clients", "TLS_WEBHOOK_CLIENTS", "comma separated client identities allowed to send webhooks", setList(&c.Server.TLS.WebhookClients)},
+
+		{"pg-user", "PG_USER", "postgres user", setString(&c.Postgress.User)},
+		{"pg-pass", "PG_PASS", "postgres password", setString(&c.Postgress.Password)},
+		{"pg-host", "PG_HOST", "postgres host[:port]", setString(&c.Postgress.Host)},
+		{"pg-db", "PG_DB_NAME", "postgres database", setString(&c.Postgress.DbName)},
+		{"pg-ssl-mode", "PG_SSL_MODE", "postgres sslmode", setString(&c.Postgress.SSLMode)},
+		{"pg-max-open-conns", "PG_MAX_OPEN_CONNS", "max open connections, 0 is unlimited", setInt(&c.Postgress.MaxOpenConns)},
+		{"pg-max-idle-conns", "PG_MAX_IDLE_CONNS", "max idle connections", setInt(&c.Postgress.MaxIdleConns)},
+		{"pg-conn-max-lifetime", "PG_CONN_MAX_LIFETIME", "max lifetime of connection", setDuration(&c.Postgress.ConnMaxLifetime)},
+		{"pg-conn-max-idle-time", "PG_CONN_MAX_IDLE_TIME", "max idle time of connection", setDuration(&c.Postgress.ConnMaxIdleTime)},
+
+		{"sqlite-path", "SQLITE_PATH", "sqlite database file", setString(&c.Sqlite.Path)},
+
+		{"status-timeouts", "ORDER_STATUS_TIMEOUTS", "status timeouts <status>=<timeout>:<to_status>,...", func(value string) error {
+			timeouts, err := parseStatusTimeouts(value)
+			if err != nil {
+				return err
+			}
+			c.StatusTimeouts = timeouts
+			return nil
+		}},
+
+		{"timeout-get-orders", "TIMEOUT_GET_ORDERS", "timeout of orders query", setDuration(&c.Timeouts.GetOrders)},
+		{"timeout-save-event", "TIMEOUT_SAVE_EVENT", "timeout of webhook processing", setDuration(&c.Timeouts.SaveEvent)},
+		{"timeout-get-events", "TIMEOUT_GET_EVENTS", "timeout of events loading", setDuration(&c.Timeouts.GetEvents)},
+		{"timeout-job", "TIMEOUT_JOB", "timeout of delayed job", setDuration(&c.Timeouts.Job)},
+		{"timeout-health", "TIMEOUT_HEALTH", "timeout of every readiness check", setDuration(&c.Timeouts.Health)},
+
+		{"archive-retention", "ARCHIVE_RETENTION", "archive events of orders final for longer, 0 disables archiving", setDuration(&c.Archive.Retention)},
+		{"archive-interval", "ARCHIVE_INTERVAL", "interval of archiving", setDuration(&c.Archive.Interval)},
+		{"archive-dir", "ARCHIVE_DIR", "directory of archived events", setString(&c.Archive.Dir)},
+		{"archive-batch-size", "ARCHIVE_BATCH_SIZE", "orders archived in one batch", setInt(&c.Archive.BatchSize)},
+
+		{"broker-buffer", "BROKER_BUFFER", "buffer of subscriber channel", setInt(&c.Broker.Buffer)},
+		{"cooldown", "SCHEDULER_COOLDOWN", "time to accept events after done status", setDuration(&c.Scheduler.Cooldown)},
+		{"stream-wait-time", "STREAM_WAIT_TIME", "inactive stream is closed after it", setDuration(&c.Stream.WaitTime)},
+		{"stream-token-secret", "STREAM_TOKEN_SECRET", "secret to sign stream tokens, random if empty", setString(&c.Stream.TokenSecret)},
+		{"stream-token-ttl", "STREAM_TOKEN_TTL", "default ttl of stream token", setDuration(&c.Stream.TokenTTL)},
+		{"stream-token-max-ttl", "STREAM_TOKEN_MAX_TTL", "max ttl of stream token", setDuration(&c.Stream.TokenMaxTTL)},
+
+		{"auth-api-keys", "AUTH_API_KEYS", "api keys <name>:<key>:<scope>|<scope>,...", func(value string) error {
+			keys, err := parseAPIKeys(value)
+			if err != nil {
+				return err
+			}
+			c.Auth.APIKeys = keys
+			return nil
+		}},
+		{"auth-jwks-file", "AUTH_JWKS_FILE", "jwks file with keys of end user tokens", setString(&c.Auth.JWKSFile)},
+		{"auth-issuer", "AUTH_JWT_ISSUER", "expected issuer of end user tokens", setString(&c.Auth.Issuer)},
+		{"auth-audience", "AUTH_JWT_AUDIENCE", "expected audience of end user tokens", setString(&c.Auth.Audience)},
+		{"auth-user-claim", "AUTH_USER_CLAIM", "claim with user id of end user", setString(&c.Auth.UserClaim)},
+
+		{"rate-webhook", "RATE_LIMIT_WEBHOOK_RATE", "webhooks per second of client, 0 disables limit", setFloat(&c.RateLimit.Webhook.Rate)},
+		{"rate-webhook-burst", "RATE_LIMIT_WEBHOOK_BURST", "burst of webhooks of client", setInt(&c.RateLimit.Webhook.Burst)},
+		{"rate-read", "RATE_LIMIT_READ_RATE", "read requests per second of client, 0 disables limit", setFloat(&c.RateLimit.Read.Rate)},
+		{"rate-read-burst", "RATE_LIMIT_READ_BURST", "burst of read requests of client", setInt(&c.RateLimit.Read.Burst)},
+		{"streams-per-user", "RATE_LIMIT_STREAMS_PER_USER", "concurrent events streams of client, 0 disables limit", setInt(&c.RateLimit.StreamsPerUser)},
+		{"streams", "RATE_LIMIT_STREAMS", "concurrent events streams of all clients, 0 disables limit", setInt(&c.RateLimit.Streams)},
+
+		{"tracing-exporter", "TRACING_EXPORTER", "spans exporter: none, stdout or otlp", setString(&c.Tracing.Exporter)},
+		{"tracing-endpoint", "TRACING_OTLP_ENDPOINT", "host:port of otlp http receiver", setString(&c.Tracing.Endpoint)},
+		{"tracing-insecure", "TRACING_OTLP_INSECURE", "send spans to otlp receiver without tls", setBool(&c.Tracing.Insecure)},
+		{"tracing-sample-ratio", "TRACING_SAMPLE_RATIO", "part of recorded traces from 0 to 1", setFloat(&c.Tracing.SampleRatio)},
+
+		{"log-level", "LOG_LEVEL", "log level: debug, info, warn or error", setString(&c.Log.Level)},
+		{"log-format", "LOG_FORMAT", "log format: text or json", setString(&c.Log.Format)},
+		{"log-redact-user-ids", "LOG_REDACT_USER_IDS", "log hashes of user ids instead of them", setBool(&c.Log.RedactUserIDs)},
+	}
+}
+
+func setString(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+func setList(p *[]string) func(string) error {
+	return func(value string) error {
+		*p = nil
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				*p = append(*p, v)
+			}
+		}
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*p = n
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*p = b
+		return nil
+	}
+}
+
+func setFloat(p *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+		return nil
+	}
+}
+
+func setDuration(p *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	}
+}
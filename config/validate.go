@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+var validScopes = map[string]bool{
+	"ingest": true,
+	"read":   true,
+	"admin":  true,
+}
+
+var tracingExporters = map[string]bool{
+	TracingNone:   true,
+	TracingStdout: true,
+	TracingOTLP:   true,
+}
+
+var logLevels = map[string]bool{
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+}
+
+var logFormats = map[string]bool{
+	LogText: true,
+	LogJSON: true,
+}
+
+// Validate returns all found problems, field names are the same as in yaml config
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+	notNegative := func(field string, d time.Duration) {
+		check(d >= 0, field, "should not be negative, got %s", d)
+	}
+	positive := func(field string, d time.Duration) {
+		check(d > 0, field, "should be positive, got %s", d)
+	}
+
+	check(c.Driver == DriverPostgres || c.Driver == DriverSqlite || c.Driver == DriverMemory,
+		"driver", "unsupported storage driver %q, use %s, %s or %s", c.Driver, DriverPostgres, DriverSqlite, DriverMemory)
+
+	_, port, err := net.SplitHostPort(c.Server.Addr)
+	if err == nil {
+		_, err = strconv.ParseUint(port, 10, 16)
+	}
+	check(err == nil, "server.addr", "should be [host]:port, got %q", c.Server.Addr)
+	notNegative("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	notNegative("server.read_timeout", c.Server.ReadTimeout)
+	notNegative("server.write_timeout", c.Server.WriteTimeout)
+	notNegative("server.idle_timeout", c.Server.IdleTimeout)
+	notNegative("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	check((c.Server.TLS.CertFile == "") == (c.Server.TLS.KeyFile == ""),
+		"server.tls", "cert_file and key_file should be set together")
+	check(c.Server.TLS.ClientCAFile == "" || c.Server.TLS.CertFile != "",
+		"server.tls.client_ca_file", "requires cert_file and key_file")
+	check(len(c.Server.TLS.WebhookClients) == 0 || c.Server.TLS.ClientCAFile != "",
+		"server.tls.webhook_clients", "requires client_ca_file")
+
+	// postgres settings are required only for postgres storage
+	if c.Driver == DriverPostgres {
+		check(c.Postgress.Host != "", "postgres.host", "required for %s driver", DriverPostgres)
+		check(c.Postgress.User != "", "postgres.user", "required for %s driver", DriverPostgres)
+		check(c.Postgress.DbName != "", "postgres.db_name", "required for %s driver", DriverPostgres)
+		check(sslModes[c.Postgress.SSLMode], "postgres.ssl_mode", "unsupported %q", c.Postgress.SSLMode)
+		check(c.Postgress.MaxOpenConns >= 0, "postgres.max_open_conns", "should not be negative")
+		check(c.Postgress.MaxIdleConns >= 0, "postgres.max_idle_conns", "should not be negative")
+		notNegative("postgres.conn_max_lifetime", c.Postgress.ConnMaxLifetime)
+		notNegative("postgres.conn_max_idle_time", c.Postgress.ConnMaxIdleTime)
+	}
+	if c.Driver == DriverSqlite {
+		check(c.Sqlite.Path != "", "sqlite.path", "required for %s driver", DriverSqlite)
+	}
+
+	for i, t := range c.StatusTimeouts {
+		field := fmt.Sprintf("status_timeouts[%d]", i)
+		check(t.Status != "" && t.ToStatus != "", field, "status and to_status are required")
+		positive(field+".timeout", t.Timeout)
+	}
+
+	notNegative("timeouts.get_orders", c.Timeouts.GetOrders)
+	notNegative("timeouts.save_event", c.Timeouts.SaveEvent)
+	notNegative("timeouts.get_events", c.Timeouts.GetEvents)
+	notNegative("timeouts.job", c.Timeouts.Job)
+	notNegative("timeouts.health", c.Timeouts.Health)
+
+	notNegative("archive.retention", c.Archive.Retention)
+	positive("archive.interval", c.Archive.Interval)
+	check(c.Archive.Dir != "", "archive.dir", "required")
+	check(c.Archive.BatchSize > 0, "archive.batch_size", "should be positive, got %d", c.Archive.BatchSize)
+
+	check(c.Broker.Buffer >= 0, "broker.buffer", "should not be negative, got %d", c.Broker.Buffer)
+	positive("scheduler.cooldown", c.Scheduler.Cooldown)
+	positive("stream.wait_time", c.Stream.WaitTime)
+	check(c.Stream.TokenSecret == "" || len(c.Stream.TokenSecret) >= minStreamTokenSecretLength, "stream.token_secret", "should have at least %d characters", minStreamTokenSecretLength)
+	positive("stream.token_ttl", c.Stream.TokenTTL)
+	check(c.Stream.TokenMaxTTL >= c.Stream.TokenTTL, "stream.token_max_ttl", "should not be less than token_ttl")
+
+	rate := func(field string, r RateConfig) {
+		check(r.Rate >= 0, field+".rate", "should not be negative, got %g", r.Rate)
+		check(r.Rate == 0 || r.Burst > 0, field+".burst", "should be positive, got %d", r.Burst)
+	}
+	rate("rate_limit.webhook", c.RateLimit.Webhook)
+	rate("rate_limit.read", c.RateLimit.Read)
+	check(c.RateLimit.StreamsPerUser >= 0, "rate_limit.streams_per_user", "should not be negative, got %d", c.RateLimit.StreamsPerUser)
+	check(c.RateLimit.Streams >= 0, "rate_limit.streams", "should not be negative, got %d", c.RateLimit.Streams)
+
+	check(tracingExporters[c.Tracing.Exporter], "tracing.exporter", "unsupported exporter %q, use none, stdout or otlp", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != TracingOTLP || c.Tracing.Endpoint != "", "tracing.endpoint", "required for otlp exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "should be from 0 to 1, got %g", c.Tracing.SampleRatio)
+
+	check(logLevels[c.Log.Level], "log.level", "unsupported level %q, use debug, info, warn or error", c.Log.Level)
+	check(logFormats[c.Log.Format], "log.format", "unsupported format %q, use text or json", c.Log.Format)
+
+	names := make(map[string]bool, len(c.Auth.APIKeys))
+	keys := make(map[string]bool, len(c.Auth.APIKeys))
+	for i, key := range c.Auth.APIKeys {
+		field := fmt.Sprintf("auth.api_keys[%d]", i)
+		check(key.Name != "" && !names[key.Name], field+".name", "should be unique and not empty")
+		check(len(key.Key) >= minAPIKeyLength, field+".key", "should have at least %d characters", minAPIKeyLength)
+		check(!keys[key.Key], field+".key", "is used by other key")
+		check(len(key.Scopes) > 0, field+".scopes", "required")
+		for _, scope := range key.Scopes {
+			check(validScopes[scope], field+".scopes", "unsupported scope %q, use ingest, read or admin", scope)
+		}
+		names[key.Name], keys[key.Key] = true, true
+	}
+	check(c.Auth.JWKSFile == "" || c.Auth.UserClaim != "", "auth.user_claim", "required for jwks_file")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
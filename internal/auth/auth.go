@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"webhooker/config"
+	"webhooker/internal/services/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrNoUser       = errors.New("token doesn't have user id")
+)
+
+type apiKey struct {
+	hash      [sha256.Size]byte
+	principal *models.Principal
+}
+
+// Authenticator checks bearer tokens: api keys of services and jwt of end users
+type Authenticator struct {
+	keys []apiKey
+	// jwks is nil if end user tokens aren't accepted
+	jwks      *JWKS
+	parser    *jwt.Parser
+	userClaim string
+}
+
+func NewAuthenticator(cfg *config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		userClaim: cfg.UserClaim,
+	}
+	for _, key := range cfg.APIKeys {
+		principal := &models.Principal{Name: key.Name}
+		for _, scope := range key.Scopes {
+			principal.Scopes = append(principal.Scopes, models.Scope(scope))
+		}
+		a.keys = append(a.keys, apiKey{
+			hash:      sha256.Sum256([]byte(key.Key)),
+			principal: principal,
+		})
+	}
+
+	if cfg.JWKSFile != "" {
+		jwks, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+
+		opts := []jwt.ParserOption{
+			jwt.WithValidMethods(jwks.Algorithms()),
+			jwt.WithExpirationRequired(),
+		}
+		if cfg.Issuer != "" {
+			opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+		}
+		if cfg.Audience != "" {
+			opts = append(opts, jwt.WithAudience(cfg.Audience))
+		}
+		a.parser = jwt.NewParser(opts...)
+	}
+	return a, nil
+}
+
+// Authenticate returns principal of token, end users can only read their own orders
+func (a *Authenticator) Authenticate(token string) (*models.Principal, error) {
+	// jwt has header, payload and signature separated by dots, api keys can't have them
+	if a.jwks != nil && strings.Count(token, ".") == 2 {
+		return a.authenticateUser(token)
+	}
+
+	// hashes have the same length, so comparison time doesn't depend on key
+	hash := sha256.Sum256([]byte(token))
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			return key.principal, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+func (a *Authenticator) authenticateUser(token string) (*models.Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, a.jwks.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	userID, _ := claims[a.userClaim].(string)
+	if userID == "" {
+		return nil, ErrNoUser
+	}
+	sub, _ := claims.GetSubject()
+	return &models.Principal{
+		Name:   sub,
+		UserID: userID,
+		Scopes: []models.Scope{models.ScopeRead},
+	}, nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"webhooker/config"
+	"webhooker/internal/services/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKid      = "key1"
+	testIssuer   = "https://id.example.com"
+	testAudience = "webhooker"
+)
+
+func writeJWKS(t *testing.T, key *ecdsa.PrivateKey) string {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	set := map[string]any{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": testKid,
+			"use": "sig",
+			"crv": "P-256",
+			"x":   encode(key.PublicKey.X.FillBytes(make([]byte, 32))),
+			"y":   encode(key.PublicKey.Y.FillBytes(make([]byte, 32))),
+		}},
+	}
+	data, err := json.Marshal(set)
+	require.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.Nil(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func Test_Authenticate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	a, err := NewAuthenticator(&config.AuthConfig{
+		APIKeys: []config.APIKey{
+			{Name: "warehouse", Key: "warehouse-secret-key", Scopes: []string{"read"}},
+			{Name: "provider", Key: "provider-secret-key", Scopes: []string{"ingest"}},
+		},
+		JWKSFile:  writeJWKS(t, key),
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		UserClaim: "sub",
+	})
+	require.Nil(t, err)
+
+	sign := func(claims jwt.MapClaims, signKey *ecdsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = testKid
+		signed, err := token.SignedString(signKey)
+		require.Nil(t, err)
+		return signed
+	}
+	claims := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "user1",
+			"iss": testIssuer,
+			"aud": testAudience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if change != nil {
+			change(c)
+		}
+		return c
+	}
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("guess"))
+	require.Nil(t, err)
+
+	testCases := []struct {
+		name   string
+		token  string
+		exp    *models.Principal
+		expErr error
+	}{
+		{
+			name:  "api key",
+			token: "warehouse-secret-key",
+			exp:   &models.Principal{Name: "warehouse", Scopes: []models.Scope{models.ScopeRead}},
+		},
+		{
+			name:   "unknown api key",
+			token:  "warehouse-secret-kez",
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:  "user token",
+			token: sign(claims(nil), key),
+			exp:   &models.Principal{Name: "user1", UserID: "user1", Scopes: []models.Scope{models.ScopeRead}},
+		},
+		{
+			name:   "expired token",
+			token:  sign(claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }), key),
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:   "token without exp",
+			token:  sign(claims(func(c jwt.MapClaims) { delete(c, "exp") }), key),
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:   "other issuer",
+			token:  sign(claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), key),
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:   "signed by other key",
+			token:  sign(claims(nil), otherKey),
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:   "hmac isn't accepted",
+			token:  hmac,
+			expErr: ErrInvalidToken,
+		},
+		{
+			name:   "token without user",
+			token:  sign(claims(func(c jwt.MapClaims) { delete(c, "sub") }), key),
+			expErr: ErrNoUser,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := a.Authenticate(tc.token)
+			assert.True(t, errors.Is(err, tc.expErr), "unexpected error %v", err)
+			assert.Equal(t, tc.exp, principal)
+		})
+	}
+}
+
+func Test_LoadJWKS_NoKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.Nil(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`), 0o600))
+
+	_, err := LoadJWKS(path)
+	assert.Equal(t, ErrNoKeys, err)
+}
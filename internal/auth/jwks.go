@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeys     = errors.New("jwks doesn't have supported keys")
+	ErrUnknownKey = errors.New("unknown key id")
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS is set of public keys to verify signature of tokens, keys are found by kid header
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+	algs []string
+}
+
+// LoadJWKS reads keys from file, keys of unsupported types and encryption keys are skipped
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks, err: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks, err: %w", err)
+	}
+
+	jwks := &JWKS{keys: make(map[string]crypto.PublicKey)}
+	algs := make(map[string]bool)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, keyAlgs, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q, err: %w", k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		jwks.keys[k.Kid] = key
+		for _, alg := range keyAlgs {
+			algs[alg] = true
+		}
+	}
+	if len(jwks.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	for alg := range algs {
+		jwks.algs = append(jwks.algs, alg)
+	}
+	return jwks, nil
+}
+
+// Algorithms allowed by keys, "none" and hmac are never allowed
+func (j *JWKS) Algorithms() []string {
+	return j.algs
+}
+
+// Keyfunc finds key by kid, token without kid is accepted only for single key
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, nil
+		}
+	}
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// publicKey returns nil key for unsupported key types
+func (k *jwk) publicKey() (crypto.PublicKey, []string, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	case "EC":
+		curves := map[string]struct {
+			curve elliptic.Curve
+			alg   string
+		}{
+			"P-256": {elliptic.P256(), "ES256"},
+			"P-384": {elliptic.P384(), "ES384"},
+			"P-521": {elliptic.P521(), "ES512"},
+		}
+		c, ok := curves[k.Crv]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !c.curve.IsOnCurve(x, y) {
+			return nil, nil, errors.New("point isn't on curve")
+		}
+		return &ecdsa.PublicKey{Curve: c.curve, X: x, Y: y}, []string{c.alg}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), []string{"EdDSA"}, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url number")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statusOK = "ok"
+	// default timeout of every check
+	defaultTimeout = 2 * time.Second
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// Check returns error if dependency isn't healthy
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type namedInfo struct {
+	name string
+	info func() any
+}
+
+// Checker runs readiness checks of dependencies, service isn't ready during shutdown
+type Checker struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	infos    []namedInfo
+	shutdown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// AddCheck adds readiness check, checks should be added before serving requests
+func (c *Checker) AddCheck(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddInfo adds details of dependency to status, for example pool stats
+func (c *Checker) AddInfo(name string, info func() any) {
+	c.infos = append(c.infos, namedInfo{name: name, info: info})
+}
+
+// Shutdown makes service unready, so orchestrator stops sending requests
+func (c *Checker) Shutdown() {
+	c.shutdown.Store(true)
+}
+
+// Result of readiness checks, Checks has "ok" or error of every check
+type Result struct {
+	Ready  bool
+	Checks map[string]string
+}
+
+// Ready runs all checks concurrently, every check has own timeout
+func (c *Checker) Ready(ctx context.Context) Result {
+	result := Result{Ready: true, Checks: make(map[string]string, len(c.checks)+1)}
+	if c.shutdown.Load() {
+		result.Ready = false
+		result.Checks["shutdown"] = ErrShuttingDown.Error()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			err := c.run(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Ready = false
+				result.Checks[nc.name] = err.Error()
+				return
+			}
+			result.Checks[nc.name] = statusOK
+		}(nc)
+	}
+	wg.Wait()
+	return result
+}
+
+// Info returns details of dependencies
+func (c *Checker) Info() map[string]any {
+	info := make(map[string]any, len(c.infos))
+	for _, ni := range c.infos {
+		info[ni.name] = ni.info()
+	}
+	return info
+}
+
+func (c *Checker) run(ctx context.Context, check Check) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// check can hang without respecting context, it's left behind after timeout
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out, err: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Checker_Ready(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	c.AddCheck("db", func(ctx context.Context) error { return nil })
+	c.AddInfo("pending_jobs", func() any { return 3 })
+
+	result := c.Ready(context.Background())
+	assert.Equal(t, Result{Ready: true, Checks: map[string]string{"db": "ok"}}, result)
+	assert.Equal(t, map[string]any{"pending_jobs": 3}, c.Info())
+
+	c.Shutdown()
+	result = c.Ready(context.Background())
+	assert.False(t, result.Ready)
+	assert.Equal(t, ErrShuttingDown.Error(), result.Checks["shutdown"])
+}
+
+func Test_Checker_Failed(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	c.AddCheck("db", func(ctx context.Context) error { return errors.New("connection refused") })
+	c.AddCheck("broker", func(ctx context.Context) error { return nil })
+	// check which ignores context
+	c.AddCheck("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	result := c.Ready(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.False(t, result.Ready)
+	assert.Equal(t, "connection refused", result.Checks["db"])
+	assert.Equal(t, "ok", result.Checks["broker"])
+	assert.Contains(t, result.Checks["slow"], "timed out")
+}
@@ -0,0 +1,81 @@
+// Package lifecycle stops service by stages in order they are added, all stages share shutdown deadline
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"webhooker/internal/logging"
+)
+
+type stage struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+type Lifecycle struct {
+	// timeout of whole shutdown, 0 waits for all stages
+	timeout time.Duration
+	stages  []stage
+}
+
+func New(timeout time.Duration) *Lifecycle {
+	return &Lifecycle{timeout: timeout}
+}
+
+// OnShutdown adds stage, stage should return when ctx is done
+func (l *Lifecycle) OnShutdown(name string, stop func(ctx context.Context) error) {
+	l.stages = append(l.stages, stage{name: name, stop: stop})
+}
+
+// Wait blocks until ctx is done or serve fails, then stops service.
+// Error of serve isn't returned if service is stopped by ctx
+func (l *Lifecycle) Wait(ctx context.Context, serve func() error) error {
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- serve() }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown is started")
+	case err = <-serveErr:
+		err = fmt.Errorf("failed to serve, err: %w", err)
+		slog.Error("shutdown after serve failed", logging.Err(err))
+	}
+	return errors.Join(err, l.Shutdown())
+}
+
+// Shutdown runs all stages, stages after deadline are still run with done ctx,
+// so resources like db are released anyway
+func (l *Lifecycle) Shutdown() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if l.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), l.timeout)
+	}
+	defer cancel()
+
+	var errs []error
+	for _, s := range l.stages {
+		start := time.Now()
+		err := s.stop(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s, err: %w", s.name, err))
+			slog.Error("failed to stop", slog.String("stage", s.name), logging.Err(err))
+			continue
+		}
+		slog.Info("stopped", slog.String("stage", s.name), slog.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
+
+// Await waits for done channel of background job until ctx is done
+func Await[T any](ctx context.Context, done <-chan T) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Shutdown(t *testing.T) {
+	var stopped []string
+	l := New(50 * time.Millisecond)
+	l.OnShutdown("http", func(ctx context.Context) error {
+		stopped = append(stopped, "http")
+		return nil
+	})
+	// stage which doesn't finish in time
+	l.OnShutdown("scheduler", func(ctx context.Context) error {
+		stopped = append(stopped, "scheduler")
+		return Await(ctx, make(chan bool))
+	})
+	l.OnShutdown("db", func(ctx context.Context) error {
+		stopped = append(stopped, "db")
+		assert.Error(t, ctx.Err())
+		return nil
+	})
+
+	start := time.Now()
+	err := l.Shutdown()
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "failed to stop scheduler")
+	assert.Equal(t, []string{"http", "scheduler", "db"}, stopped)
+}
+
+func Test_Wait(t *testing.T) {
+	testCases := []struct {
+		name   string
+		serve  func(stop <-chan struct{}) error
+		cancel bool
+		expErr string
+	}{
+		{
+			name: "stopped by signal",
+			serve: func(stop <-chan struct{}) error {
+				<-stop
+				return errors.New("server closed")
+			},
+			cancel: true,
+		},
+		{
+			name: "serve failed",
+			serve: func(stop <-chan struct{}) error {
+				return errors.New("address already in use")
+			},
+			expErr: "failed to serve, err: address already in use",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stop := make(chan struct{})
+			l := New(time.Second)
+			l.OnShutdown("http", func(ctx context.Context) error {
+				close(stop)
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			err := l.Wait(ctx, func() error { return tc.serve(stop) })
+			if tc.expErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expErr)
+			}
+			// shutdown is run in both cases
+			_, open := <-stop
+			assert.False(t, open)
+		})
+	}
+}
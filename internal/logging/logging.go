@@ -0,0 +1,117 @@
+// Package logging configures slog, attributes of request are carried in context and added to every record
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"webhooker/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// keys of attributes which are used across service
+const (
+	KeyRequestID = "request_id"
+	KeyOrderID   = "order_id"
+	KeyEventID   = "event_id"
+	KeyClientID  = "client_id"
+	KeyStreamID  = "stream_id"
+	KeyUserID    = "user_id"
+	// KeySubject is subject of token of end user, it's redacted as user id
+	KeySubject = "subject"
+	KeyError   = "err"
+)
+
+type attrsKey struct{}
+
+// Setup sets default logger, log package writes to it too
+func Setup(cfg *config.LogConfig) {
+	slog.SetDefault(New(os.Stderr, cfg))
+}
+
+// New creates logger which adds attributes of context to records
+func New(w io.Writer, cfg *config.LogConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.RedactUserIDs {
+		opts.ReplaceAttr = redactUserID
+	}
+
+	var handler slog.Handler
+	if cfg.Format == config.LogJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(&contextHandler{next: handler})
+}
+
+// With returns context whose records have attrs, attr replaces previous one with the same key
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(prev)+len(attrs))
+	for _, p := range prev {
+		if !hasKey(attrs, p.Key) {
+			merged = append(merged, p)
+		}
+	}
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// Err is attribute of error
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// contextHandler adds attributes of context and ids of span, so logs can be found by trace
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
+
+// redactUserID replaces user id and subject of end user with prefix of its hash
+func redactUserID(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != KeyUserID && a.Key != KeySubject {
+		return a
+	}
+	sum := sha256.Sum256([]byte(a.Value.String()))
+	return slog.String(a.Key, "sha256:"+hex.EncodeToString(sum[:6]))
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"webhooker/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func Test_Context(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, &config.LogConfig{Level: "info", Format: config.LogJSON})
+
+	ctx := With(context.Background(), slog.String(KeyRequestID, "req-1"), slog.String(KeyOrderID, "order-1"))
+	// the same key replaces previous value
+	ctx = With(ctx, slog.String(KeyOrderID, "order-2"), slog.String(KeyEventID, "event-1"))
+	logger.ErrorContext(ctx, "failed to save event", Err(errors.New("connection refused")))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "failed to save event", record["msg"])
+	assert.Equal(t, "req-1", record[KeyRequestID])
+	assert.Equal(t, "order-2", record[KeyOrderID])
+	assert.Equal(t, "event-1", record[KeyEventID])
+	assert.Equal(t, "connection refused", record[KeyError])
+	assert.Equal(t, 1, strings.Count(buf.String(), KeyOrderID))
+}
+
+func Test_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, &config.LogConfig{Level: "warn", Format: config.LogText})
+
+	logger.Info("request is served")
+	assert.Empty(t, buf.String())
+
+	logger.Warn("request timeout")
+	assert.Contains(t, buf.String(), `level=WARN msg="request timeout"`)
+}
+
+func Test_RedactUserID(t *testing.T) {
+	testCases := []struct {
+		name   string
+		redact bool
+		exp    func(t *testing.T, out string)
+	}{
+		{
+			name: "user id is logged",
+			exp: func(t *testing.T, out string) {
+				assert.Contains(t, out, "user_id=user1")
+				assert.Contains(t, out, "subject=sub1")
+			},
+		},
+		{
+			name:   "user id is replaced by hash",
+			redact: true,
+			exp: func(t *testing.T, out string) {
+				assert.NotContains(t, out, "user1")
+				assert.NotContains(t, out, "sub1")
+				assert.Contains(t, out, "user_id=sha256:")
+				assert.Contains(t, out, "subject=sha256:")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, &config.LogConfig{Level: "info", Format: config.LogText, RedactUserIDs: tc.redact})
+
+			ctx := With(context.Background(), slog.String(KeyUserID, "user1"), slog.String(KeySubject, "sub1"))
+			logger.InfoContext(ctx, "stream is opened")
+			// attrs of logger are redacted too
+			logger.With(KeyUserID, "user1").Info("stream is closed")
+
+			tc.exp(t, buf.String())
+		})
+	}
+}
+
+func Test_TraceID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, &config.LogConfig{Level: "info", Format: config.LogJSON})
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	logger.InfoContext(ctx, "request is served")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), record["span_id"])
+}
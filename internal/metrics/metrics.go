@@ -0,0 +1,141 @@
+// Package metrics has prometheus metrics of service, they are served by /metrics
+package metrics
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "webhooker"
+
+// outcomes of received webhook
+const (
+	OutcomeAccepted   = "accepted"
+	OutcomeDuplicate  = "duplicate"
+	OutcomeAfterFinal = "after_final"
+	OutcomeConflict   = "conflict"
+	OutcomeInvalid    = "invalid"
+	OutcomeError      = "error"
+)
+
+// Registry has only metrics of service and go runtime, default registry can be polluted by dependencies
+var Registry = prometheus.NewRegistry()
+
+var (
+	Webhooks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_total",
+		Help:      "Received webhooks by outcome and order status.",
+	}, []string{"outcome", "status"})
+
+	SaveEventDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "save_event_duration_seconds",
+		Help:      "Duration of webhook event processing.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of storage operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"driver", "operation"})
+
+	// EventLag is time between update of order by provider and receipt of webhook
+	EventLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_lag_seconds",
+		Help:      "Time from provider updated_at to webhook receipt.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600},
+	})
+
+	ActiveStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Open events streams.",
+	})
+
+	// HeldEvents are received by streams but not sent, they wait for events with previous statuses
+	HeldEvents = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_held_events",
+		Help:      "Events held back by streams waiting for missing statuses.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Webhooks,
+		SaveEventDuration,
+		DBQueryDuration,
+		EventLag,
+		ActiveStreams,
+		HeldEvents,
+	)
+}
+
+// RegisterBroker adds gauges of all subscribers and of topics by their subscribers,
+// order ids aren't used as labels, there are as many topics as streamed orders
+func RegisterBroker(subscribers func() map[string]int) {
+	Registry.MustRegister(&subscribersCollector{subscribers: subscribers})
+}
+
+// RegisterDelay adds gauge of delayed jobs which aren't run yet
+func RegisterDelay(pending func() int) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "delayed_jobs",
+		Help:      "Delayed jobs waiting to run.",
+	}, func() float64 { return float64(pending()) }))
+}
+
+// topicBuckets split topics by count of subscribers, max is inclusive
+var topicBuckets = []struct {
+	max   int
+	label string
+}{{1, "1"}, {2, "2"}, {5, "3-5"}, {20, "6-20"}, {math.MaxInt, "21+"}}
+
+var (
+	subscribersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "broker", "subscribers"),
+		"Broker subscribers of all topics.",
+		nil, nil,
+	)
+	topicsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "broker", "topics"),
+		"Broker topics by count of subscribers.",
+		[]string{"subscribers"}, nil,
+	)
+)
+
+// subscribersCollector reads broker on scrape, topics are removed when last subscriber leaves
+type subscribersCollector struct {
+	subscribers func() map[string]int
+}
+
+func (c *subscribersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- subscribersDesc
+	ch <- topicsDesc
+}
+
+func (c *subscribersCollector) Collect(ch chan<- prometheus.Metric) {
+	total := 0
+	topics := make([]int, len(topicBuckets))
+	for _, count := range c.subscribers() {
+		total += count
+		i := 0
+		for count > topicBuckets[i].max {
+			i++
+		}
+		topics[i]++
+	}
+	ch <- prometheus.MustNewConstMetric(subscribersDesc, prometheus.GaugeValue, float64(total))
+	for i, bucket := range topicBuckets {
+		ch <- prometheus.MustNewConstMetric(topicsDesc, prometheus.GaugeValue, float64(topics[i]), bucket.label)
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SubscribersCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&subscribersCollector{subscribers: func() map[string]int {
+		return map[string]int{"order1": 2, "order2": 1, "order3": 1, "order4": 4, "order5": 30}
+	}})
+
+	families, err := registry.Gather()
+	require.Nil(t, err)
+	require.Len(t, families, 2)
+	assert.Equal(t, "webhooker_broker_subscribers", families[0].GetName())
+	require.Len(t, families[0].GetMetric(), 1)
+	assert.Empty(t, families[0].GetMetric()[0].GetLabel())
+	assert.Equal(t, float64(38), families[0].GetMetric()[0].GetGauge().GetValue())
+
+	assert.Equal(t, "webhooker_broker_topics", families[1].GetName())
+	got := make(map[string]float64)
+	for _, m := range families[1].GetMetric() {
+		got[label(m, "subscribers")] = m.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"1": 2, "2": 1, "3-5": 1, "6-20": 0, "21+": 1}, got)
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -10,12 +10,15 @@ type Broker struct {
 	subs   map[string]map[string]chan *models.Event
 	quit   chan struct{}
 	closed bool
+	// buffer of subscriber channel, publisher waits for subscriber if it is full
+	buffer int
 }
 
-func NewBroker() *Broker {
+func NewBroker(buffer int) *Broker {
 	return &Broker{
-		subs: make(map[string]map[string]chan *models.Event),
-		quit: make(chan struct{}),
+		subs:   make(map[string]map[string]chan *models.Event),
+		quit:   make(chan struct{}),
+		buffer: buffer,
 	}
 }
 
@@ -43,7 +46,7 @@ func (b *Broker) Subscribe(clientId string, topic string) chan *models.Event {
 		return nil
 	}
 
-	ch := make(chan *models.Event)
+	ch := make(chan *models.Event, b.buffer)
 
 	if _, ok := b.subs[topic]; !ok {
 		b.subs[topic] = make(map[string]chan *models.Event)
@@ -61,7 +64,45 @@ func (b *Broker) UnSubscribe(clientId string, topic string) {
 		return
 	}
 
-	delete(b.subs[topic], clientId)
+	// closed channel stops subscriber which reads it until unsubscribe
+	if ch, ok := b.subs[topic][clientId]; ok {
+		close(ch)
+		delete(b.subs[topic], clientId)
+	}
+	if len(b.subs[topic]) == 0 {
+		delete(b.subs, topic)
+	}
+}
+
+// Closed is true after Close, broker doesn't accept subscribers
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Subscribers returns count of active subscriptions
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := 0
+	for _, clients := range b.subs {
+		count += len(clients)
+	}
+	return count
+}
+
+// SubscribersByTopic returns count of subscriptions of topics which have subscribers
+func (b *Broker) SubscribersByTopic() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	counts := make(map[string]int, len(b.subs))
+	for topic, clients := range b.subs {
+		counts[topic] = len(clients)
+	}
+	return counts
 }
 
 func (b *Broker) Close() {
@@ -75,15 +116,10 @@ func (b *Broker) Close() {
 	b.closed = true
 	close(b.quit)
 
-	for _, ch := range b.subs {
-		for _, sub := range ch {
-			close(sub)
-		}
-	}
-
-	for _, clients := range b.subs {
+	for topic, clients := range b.subs {
 		for _, ch := range clients {
 			close(ch)
 		}
+		delete(b.subs, topic)
 	}
 }
@@ -8,8 +8,8 @@ import (
 )
 
 func Test_Broker(t *testing.T) {
-	// Create a new agent
-	agent := NewBroker()
+	// Create a new agent, buffer lets publish not wait for clients which are read in other order
+	agent := NewBroker(1)
 
 	// Subscribe to a topic
 	client1 := agent.Subscribe("client1", "order1")
@@ -31,3 +31,31 @@ func Test_Broker(t *testing.T) {
 	assert.Equal(t, "event1", eventFromClient1.EventID)
 	assert.Equal(t, "event1", eventFromClient2.EventID)
 }
+
+func Test_Broker_Close(t *testing.T) {
+	broker := NewBroker(1)
+
+	client1 := broker.Subscribe("client1", "order1")
+	client2 := broker.Subscribe("client2", "order2")
+	client3 := broker.Subscribe("client3", "order2")
+	broker.UnSubscribe("client3", "order2")
+
+	// unsubscribed channel is closed once, close of broker doesn't close it again
+	_, ok := <-client3
+	assert.False(t, ok)
+
+	broker.Close()
+	broker.Close()
+
+	_, ok = <-client1
+	assert.False(t, ok)
+	_, ok = <-client2
+	assert.False(t, ok)
+	assert.True(t, broker.Closed())
+	assert.Equal(t, 0, broker.Subscribers())
+	assert.Nil(t, broker.Subscribe("client4", "order1"))
+
+	// publish and unsubscribe after close are ignored
+	broker.Publish("order1", &models.Event{EventID: "event1"})
+	broker.UnSubscribe("client1", "order1")
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// full buckets are removed after this interval, they are the same as missing ones
+const cleanupInterval = time.Minute
+
+// Limit of token bucket, Rate tokens per second are added up to Burst. Zero Rate disables limit
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps token bucket per key, for example per client of route
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	cleanedAt time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow takes token from bucket of key, returns time till next token if bucket is empty
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.cleanedAt) >= cleanupInterval {
+		l.cleanup(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Usage of client is part of burst which is used now
+type Usage struct {
+	Key  string
+	Used float64
+}
+
+// Usage returns clients which used tokens, most active first
+func (l *Limiter) Usage() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	usage := make([]Usage, 0, len(l.buckets))
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if used := float64(l.limit.Burst) - b.tokens; used > 0 {
+			usage = append(usage, Usage{Key: key, Used: math.Round(used*100) / 100})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Used == usage[j].Used {
+			return usage[i].Key < usage[j].Key
+		}
+		return usage[i].Used > usage[j].Used
+	})
+	return usage
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+}
+
+func (l *Limiter) cleanup(now time.Time) {
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.cleanedAt = now
+}
+
+// Concurrency limits concurrent operations per key and in total, zero limit isn't checked
+type Concurrency struct {
+	perKey int
+	total  int
+
+	mu     sync.Mutex
+	active map[string]int
+	count  int
+}
+
+func NewConcurrency(perKey int, total int) *Concurrency {
+	return &Concurrency{
+		perKey: perKey,
+		total:  total,
+		active: make(map[string]int),
+	}
+}
+
+// Acquire returns false if limit is reached, release should be called when operation is finished
+func (c *Concurrency) Acquire(key string) (func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.total > 0 && c.count >= c.total {
+		return nil, false
+	}
+	if c.perKey > 0 && c.active[key] >= c.perKey {
+		return nil, false
+	}
+	c.active[key]++
+	c.count++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.count--
+			if c.active[key]--; c.active[key] == 0 {
+				delete(c.active, key)
+			}
+		})
+	}, true
+}
+
+// Usage returns all active operations and active operations per key
+func (c *Concurrency) Usage() (int, map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := make(map[string]int, len(c.active))
+	for key, n := range c.active {
+		active[key] = n
+	}
+	return c.count, active
+}
+
+func (c *Concurrency) Limits() (int, int) {
+	return c.perKey, c.total
+}
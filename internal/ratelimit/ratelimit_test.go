@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limiter(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Limit{Rate: 2, Burst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("client1")
+		require.True(t, ok, "request %d", i)
+	}
+	ok, retryAfter := l.Allow("client1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// other client has own bucket
+	ok, _ = l.Allow("client2")
+	assert.True(t, ok)
+
+	assert.Equal(t, []Usage{{Key: "client1", Used: 3}, {Key: "client2", Used: 1}}, l.Usage())
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("client1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("client1")
+	assert.False(t, ok)
+
+	// full buckets are removed
+	now = now.Add(time.Minute)
+	ok, _ = l.Allow("client3")
+	assert.True(t, ok)
+	assert.Len(t, l.buckets, 1)
+}
+
+func Test_Limiter_Disabled(t *testing.T) {
+	l := NewLimiter(Limit{})
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("client1")
+		require.True(t, ok)
+	}
+}
+
+func Test_Concurrency(t *testing.T) {
+	c := NewConcurrency(2, 3)
+
+	release1, ok := c.Acquire("user1")
+	require.True(t, ok)
+	_, ok = c.Acquire("user1")
+	require.True(t, ok)
+	_, ok = c.Acquire("user1")
+	assert.False(t, ok, "limit per key")
+
+	_, ok = c.Acquire("user2")
+	require.True(t, ok)
+	_, ok = c.Acquire("user3")
+	assert.False(t, ok, "total limit")
+
+	release1()
+	release1()
+	total, active := c.Usage()
+	assert.Equal(t, 2, total)
+	assert.Equal(t, map[string]int{"user1": 1, "user2": 1}, active)
+
+	_, ok = c.Acquire("user3")
+	assert.True(t, ok)
+}
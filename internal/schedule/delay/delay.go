@@ -1,6 +1,7 @@
 package delay
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hmgle/delaytask"
@@ -8,22 +9,87 @@ import (
 
 type Delay struct {
 	Task *delaytask.Task
+
+	mu sync.Mutex
+	// pending jobs by id, generation of job protects from replaced job
+	pending    map[string]uint64
+	generation uint64
+	stopped    bool
 }
 
 func NewDelay() *Delay {
 	return &Delay{
-		Task: delaytask.New(),
+		Task:    delaytask.New(),
+		pending: make(map[string]uint64),
 	}
 }
 
+// AddJobFn schedules job, jobs added after Stop are ignored
 func (d *Delay) AddJobFn(id string, fn func(), delay time.Duration) {
-	d.Task.AddJobFn(id, fn, delay)
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.generation++
+	generation := d.generation
+	d.pending[id] = generation
+	d.mu.Unlock()
+
+	d.Task.AddJobFn(id, func() {
+		d.mu.Lock()
+		if d.pending[id] == generation {
+			delete(d.pending, id)
+		}
+		d.mu.Unlock()
+		fn()
+	}, delay)
 }
 
 func (d *Delay) Cancel(id string) {
+	d.mu.Lock()
+	delete(d.pending, id)
+	d.mu.Unlock()
+
 	d.Task.Cancel(id)
 }
 
+// Pending returns count of jobs which aren't run yet
+func (d *Delay) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.pending)
+}
+
+// Running is false after GracefulExit
+func (d *Delay) Running() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.stopped
+}
+
 func (d *Delay) GracefulExit() <-chan bool {
+	d.mu.Lock()
+	d.stopped = true
+	d.mu.Unlock()
+
+	return d.Task.GracefulExit()
+}
+
+// Stop cancels jobs which aren't run yet and waits for running ones,
+// unlike GracefulExit it doesn't run jobs before their time
+func (d *Delay) Stop() <-chan bool {
+	d.mu.Lock()
+	d.stopped = true
+	ids := make([]string, 0, len(d.pending))
+	for id := range d.pending {
+		ids = append(ids, id)
+	}
+	clear(d.pending)
+	d.mu.Unlock()
+
+	for _, id := range ids {
+		d.Task.Cancel(id)
+	}
 	return d.Task.GracefulExit()
 }
@@ -1,6 +1,7 @@
 package delay
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -50,3 +51,53 @@ func Test_Delay_ShotDown(t *testing.T) {
 
 	assert.Equal(t, 2, a)
 }
+
+func Test_Delay_Pending(t *testing.T) {
+	delay := NewDelay()
+
+	delay.AddJobFn("job1", func() {}, time.Hour)
+	delay.AddJobFn("job2", func() {}, time.Millisecond)
+	// replaced job is counted once
+	delay.AddJobFn("job1", func() {}, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, delay.Pending())
+
+	delay.Cancel("job1")
+	assert.Equal(t, 0, delay.Pending())
+
+	assert.True(t, delay.Running())
+	<-delay.GracefulExit()
+	assert.False(t, delay.Running())
+}
+
+func Test_Delay_Stop(t *testing.T) {
+	delay := NewDelay()
+
+	var mu sync.Mutex
+	var run []string
+	job := func(id string) func() {
+		return func() {
+			mu.Lock()
+			run = append(run, id)
+			mu.Unlock()
+		}
+	}
+
+	delay.AddJobFn("running", func() {
+		time.Sleep(50 * time.Millisecond)
+		job("running")()
+	}, time.Millisecond)
+	delay.AddJobFn("pending", job("pending"), time.Hour)
+	time.Sleep(10 * time.Millisecond)
+
+	// running job is finished, pending job isn't run
+	<-delay.Stop()
+	assert.Equal(t, []string{"running"}, run)
+	assert.Equal(t, 0, delay.Pending())
+	assert.False(t, delay.Running())
+
+	delay.AddJobFn("late", job("late"), time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, delay.Pending())
+	assert.Equal(t, []string{"running"}, run)
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"webhooker/internal/services/models"
+)
+
+var ErrForbidden = errors.New("access to orders of other users is forbidden")
+
+type principalKey struct{}
+
+// WithPrincipal stores authenticated caller in context, services limit end users to their own orders
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns nil if auth is disabled or call is internal
+func PrincipalFromContext(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*models.Principal)
+	return principal
+}
+
+// restrictUsers replaces empty user ids with own user of end user, other users are forbidden
+func restrictUsers(ctx context.Context, userIDs []string) ([]string, error) {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil || !principal.IsUser() {
+		return userIDs, nil
+	}
+	if userIDs == nil {
+		return []string{principal.UserID}, nil
+	}
+	for _, id := range userIDs {
+		if id != principal.UserID {
+			return nil, ErrForbidden
+		}
+	}
+	return userIDs, nil
+}
+
+// canAccess is false if end user requests order of other user
+func canAccess(ctx context.Context, order *models.Order) bool {
+	principal := PrincipalFromContext(ctx)
+	return principal == nil || !principal.IsUser() || principal.UserID == order.UserID
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetOrders_EndUser(t *testing.T) {
+	var (
+		isFinal   = true
+		limit     = defaultLimit
+		offset    = defaultOffset
+		sortBy    = defaultSortBy
+		sortOrder = defaultSortOrder
+		user      = &models.Principal{Name: "user1", UserID: "user1", Scopes: []models.Scope{models.ScopeRead}}
+		service   = &models.Principal{Name: "warehouse", Scopes: []models.Scope{models.ScopeRead}}
+	)
+
+	testCases := []struct {
+		name      string
+		principal *models.Principal
+		filter    *models.OrderFilter
+		prepare   func(*apiMock.MockOrderStorage)
+		expErr    error
+	}{
+		{
+			name:      "user gets only own orders",
+			principal: user,
+			filter:    &models.OrderFilter{IsFinal: &isFinal},
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					UserIDs:   []string{"user1"},
+					IsFinal:   &isFinal,
+					Limit:     &limit,
+					Offset:    &offset,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+				}).Return(nil, nil)
+			},
+		},
+		{
+			name:      "user without other filters",
+			principal: user,
+			filter:    &models.OrderFilter{},
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					UserIDs:   []string{"user1"},
+					Limit:     &limit,
+					Offset:    &offset,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+				}).Return(nil, nil)
+			},
+		},
+		{
+			name:      "failed. user requests other user",
+			principal: user,
+			filter:    &models.OrderFilter{UserIDs: []string{"user1", "user2"}},
+			expErr:    ErrForbidden,
+		},
+		{
+			name:      "service isn't limited",
+			principal: service,
+			filter:    &models.OrderFilter{UserIDs: []string{"user2"}},
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					UserIDs:   []string{"user2"},
+					Limit:     &limit,
+					Offset:    &offset,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+				}).Return(nil, nil)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(orderStorageMock)
+			}
+
+			s := NewOrderService(orderStorageMock, nil, Timeouts{})
+
+			ctx := WithPrincipal(context.Background(), tc.principal)
+			_, err := s.GetOrders(ctx, tc.filter)
+			assert.Equal(t, tc.expErr, err)
+		})
+	}
+}
+
+func Test_GetTimeline_OtherUser(t *testing.T) {
+	ctr := gomock.NewController(t)
+	defer ctr.Finish()
+
+	orderID := "order1"
+	orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+	orderStorageMock.EXPECT().GetOrder(gomock.Any(), orderID).
+		Return(&models.Order{ID: orderID, UserID: "user2", CreateAt: time.Now()}, nil)
+
+	s := NewOrderService(orderStorageMock, nil, Timeouts{})
+
+	ctx := WithPrincipal(context.Background(), &models.Principal{UserID: "user1"})
+	_, err := s.GetTimeline(ctx, orderID)
+	assert.Equal(t, ErrOrderNotFound, err)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Timeouts limits duration of operations, zero value means no deadline
+type Timeouts struct {
+	GetOrders time.Duration
+	SaveEvent time.Duration
+	// loading of order and events before streaming
+	GetEvents time.Duration
+	// delayed jobs: finalization after cooldown and status timeouts
+	Job time.Duration
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError keeps context error in chain, drivers can return own errors after cancellation
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ctx.Err(), err)
+}
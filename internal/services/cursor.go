@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+	"webhooker/internal/services/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type cursorPayload struct {
+	SortBy    models.SortBy    `json:"s"`
+	SortOrder models.SortOrder `json:"o"`
+	At        time.Time        `json:"t"`
+	OrderID   string           `json:"id"`
+}
+
+func newCursor(order *models.Order, sortBy models.SortBy, sortOrder models.SortOrder) *models.OrderCursor {
+	at := order.CreateAt
+	if sortBy == models.UpdateAt {
+		at = order.UpdateAt
+	}
+	return &models.OrderCursor{
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+		At:        at,
+		OrderID:   order.ID,
+	}
+}
+
+func encodeCursor(c *models.OrderCursor) string {
+	data, _ := json.Marshal(cursorPayload{
+		SortBy:    c.SortBy,
+		SortOrder: c.SortOrder,
+		At:        c.At,
+		OrderID:   c.OrderID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*models.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var p cursorPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if p.OrderID == "" ||
+		(p.SortBy != models.CreateAt && p.SortBy != models.UpdateAt) ||
+		(p.SortOrder != models.SortAsc && p.SortOrder != models.SortDesc) {
+		return nil, ErrInvalidCursor
+	}
+	return &models.OrderCursor{
+		SortBy:    p.SortBy,
+		SortOrder: p.SortOrder,
+		At:        p.At,
+		OrderID:   p.OrderID,
+	}, nil
+}
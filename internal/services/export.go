@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"webhooker/internal/services/models"
+)
+
+// exportPageSize is number of orders which events are loaded by one query
+const exportPageSize = 100
+
+// ExportFunc receives order, its events when requested and cursor to resume export after this order
+type ExportFunc func(order *models.Order, events []*models.Event, cursor string) error
+
+// ExportOrders streams all orders matching filter without limit,
+// empty cursor starts export from the beginning
+func (s *OrderService) ExportOrders(ctx context.Context, filter *models.OrderFilter, cursor string, withEvents bool, fn ExportFunc) error {
+	if filter.Offset != nil {
+		return ErrCursorWithOffset
+	}
+	filter, err := ownFilter(ctx, filter)
+	if err != nil {
+		return err
+	}
+	orderFilter, err := prepareFilter(filter)
+	if err != nil {
+		return err
+	}
+	orderFilter.Limit = nil
+	orderFilter.Offset = nil
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		// cursor is valid only for the same sorting
+		if after.SortBy != *orderFilter.SortBy || after.SortOrder != *orderFilter.SortOrder {
+			return ErrInvalidCursor
+		}
+		orderFilter.After = after
+	}
+
+	if !withEvents {
+		err = s.orderStorage.IterateOrders(ctx, orderFilter, func(order *models.Order) error {
+			next := newCursor(order, *orderFilter.SortBy, *orderFilter.SortOrder)
+			return fn(order, nil, encodeCursor(next))
+		})
+		return contextError(ctx, err)
+	}
+	return contextError(ctx, s.exportWithEvents(ctx, orderFilter, fn))
+}
+
+// exportWithEvents reads orders by pages, events of page are loaded by one query after rows of orders are closed,
+// so export doesn't hold two connections and doesn't query events for every order
+func (s *OrderService) exportWithEvents(ctx context.Context, filter *models.OrderFilter, fn ExportFunc) error {
+	limit := exportPageSize
+	page := *filter
+	page.Limit = &limit
+	for {
+		var orders []*models.Order
+		err := s.orderStorage.IterateOrders(ctx, &page, func(order *models.Order) error {
+			orders = append(orders, order)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(orders))
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		events, err := s.ordersEvents(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			next := newCursor(order, *filter.SortBy, *filter.SortOrder)
+			if err := fn(order, events[order.ID], encodeCursor(next)); err != nil {
+				return err
+			}
+			page.After = next
+		}
+		if len(orders) < limit {
+			return nil
+		}
+	}
+}
+
+// ordersEvents groups events of orders by order id
+func (s *OrderService) ordersEvents(ctx context.Context, orderIDs []string) (map[string][]*models.Event, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.GetEvents)
+	defer cancel()
+
+	events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderIDs: orderIDs})
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	byOrder := make(map[string][]*models.Event, len(orderIDs))
+	for _, event := range events {
+		byOrder[event.OrderID] = append(byOrder[event.OrderID], event)
+	}
+	return byOrder, nil
+}
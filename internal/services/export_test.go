@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_ExportOrders(t *testing.T) {
+	var (
+		isFinal   = true
+		offset    = 1
+		sortBy    = models.CreateAt
+		sortOrder = models.SortDesc
+		sortAsc   = models.SortAsc
+		first     = &models.Order{ID: "2", CreateAt: time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC)}
+		second    = &models.Order{ID: "1", CreateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC)}
+		event     = &models.Event{EventID: "e1", OrderID: second.ID}
+		cursor    = encodeCursor(newCursor(first, sortBy, sortOrder))
+		pageSize  = exportPageSize
+	)
+
+	// page of orders and one more order for the next page
+	var page, pageEvents []*models.Order
+	for i := 0; i <= exportPageSize; i++ {
+		page = append(page, &models.Order{ID: fmt.Sprintf("p%03d", i), CreateAt: second.CreateAt.Add(-time.Duration(i) * time.Second)})
+	}
+	pageEvents = page[:exportPageSize]
+	lastOfPage := newCursor(page[exportPageSize-1], sortBy, sortOrder)
+	pageIDs := make([]string, 0, exportPageSize)
+	for _, o := range pageEvents {
+		pageIDs = append(pageIDs, o.ID)
+	}
+
+	type row struct {
+		order  *models.Order
+		events []*models.Event
+		cursor string
+	}
+
+	testCases := []struct {
+		name       string
+		filter     *models.OrderFilter
+		cursor     string
+		withEvents bool
+		prepare    func(*apiMock.MockOrderStorage, *apiMock.MockEventStorage)
+		exp        []row
+		expErr     error
+	}{
+		{
+			name:   "all orders without limit",
+			filter: &models.OrderFilter{IsFinal: &isFinal},
+			prepare: func(o *apiMock.MockOrderStorage, _ *apiMock.MockEventStorage) {
+				o.EXPECT().IterateOrders(gomock.Any(), &models.OrderFilter{
+					IsFinal:   &isFinal,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+				}, gomock.Any()).DoAndReturn(iterate(first, second))
+			},
+			exp: []row{
+				{order: first, cursor: cursor},
+				{order: second, cursor: encodeCursor(newCursor(second, sortBy, sortOrder))},
+			},
+		},
+		{
+			name:       "resume with events",
+			filter:     &models.OrderFilter{IsFinal: &isFinal},
+			cursor:     cursor,
+			withEvents: true,
+			prepare: func(o *apiMock.MockOrderStorage, e *apiMock.MockEventStorage) {
+				o.EXPECT().IterateOrders(gomock.Any(), &models.OrderFilter{
+					IsFinal:   &isFinal,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+					Limit:     &pageSize,
+					After: &models.OrderCursor{
+						SortBy:    sortBy,
+						SortOrder: sortOrder,
+						At:        first.CreateAt,
+						OrderID:   first.ID,
+					},
+				}, gomock.Any()).DoAndReturn(iterate(second))
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderIDs: []string{second.ID}}).
+					Return([]*models.Event{event}, nil)
+			},
+			exp: []row{
+				{order: second, events: []*models.Event{event}, cursor: encodeCursor(newCursor(second, sortBy, sortOrder))},
+			},
+		},
+		{
+			name:       "events are loaded by pages after orders are read",
+			filter:     &models.OrderFilter{IsFinal: &isFinal},
+			withEvents: true,
+			prepare: func(o *apiMock.MockOrderStorage, e *apiMock.MockEventStorage) {
+				gomock.InOrder(
+					o.EXPECT().IterateOrders(gomock.Any(), &models.OrderFilter{
+						IsFinal:   &isFinal,
+						SortBy:    &sortBy,
+						SortOrder: &sortOrder,
+						Limit:     &pageSize,
+					}, gomock.Any()).DoAndReturn(iterate(pageEvents...)),
+					e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderIDs: pageIDs}).
+						Return([]*models.Event{{EventID: "e1", OrderID: pageIDs[0]}}, nil),
+					o.EXPECT().IterateOrders(gomock.Any(), &models.OrderFilter{
+						IsFinal:   &isFinal,
+						SortBy:    &sortBy,
+						SortOrder: &sortOrder,
+						Limit:     &pageSize,
+						After:     lastOfPage,
+					}, gomock.Any()).DoAndReturn(iterate(page[exportPageSize])),
+					e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderIDs: []string{page[exportPageSize].ID}}).
+						Return(nil, nil),
+				)
+			},
+			exp: func() []row {
+				var rows []row
+				for i, o := range page {
+					r := row{order: o, cursor: encodeCursor(newCursor(o, sortBy, sortOrder))}
+					if i == 0 {
+						r.events = []*models.Event{{EventID: "e1", OrderID: o.ID}}
+					}
+					rows = append(rows, r)
+				}
+				return rows
+			}(),
+		},
+		{
+			name:   "failed. cursor for another sort order",
+			filter: &models.OrderFilter{IsFinal: &isFinal, SortOrder: &sortAsc},
+			cursor: cursor,
+			expErr: ErrInvalidCursor,
+		},
+		{
+			name:   "failed. offset",
+			filter: &models.OrderFilter{IsFinal: &isFinal, Offset: &offset},
+			expErr: ErrCursorWithOffset,
+		},
+		{
+			name:   "failed. no filters",
+			filter: &models.OrderFilter{},
+			expErr: ErrFilterRequired,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			eventStorageMock := apiMock.NewMockEventStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(orderStorageMock, eventStorageMock)
+			}
+
+			s := NewOrderService(orderStorageMock, eventStorageMock, Timeouts{})
+
+			var res []row
+			err := s.ExportOrders(context.Background(), tc.filter, tc.cursor, tc.withEvents, func(order *models.Order, events []*models.Event, cursor string) error {
+				res = append(res, row{order: order, events: events, cursor: cursor})
+				return nil
+			})
+			assert.Equal(t, tc.expErr, err)
+			assert.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+func iterate(orders ...*models.Order) func(context.Context, *models.OrderFilter, func(*models.Order) error) error {
+	return func(_ context.Context, _ *models.OrderFilter, fn func(*models.Order) error) error {
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
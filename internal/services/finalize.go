@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"webhooker/internal/services/models"
+)
+
+var (
+	ErrNotInCooldown = errors.New("order isn't in cooldown, it should be in done status and not final")
+	ErrNoStatusEvent = errors.New("event of order status not found")
+)
+
+// Finalize makes not final order final in its current status, e.g. order stuck without webhooks.
+// Jobs of order which are scheduled by other instance see final order and do nothing
+func (s *WebhookService) Finalize(ctx context.Context, orderID string) (*models.Order, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.SaveEvent)
+	defer cancel()
+
+	order, err := s.orderStorage.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order, err: %w", err)
+	}
+	if order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.IsFinal {
+		return nil, models.ErrAfterFinal
+	}
+
+	events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderID: &orderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events, err: %w", err)
+	}
+	event := searchEventByStatus(events, order.Status)
+	if event == nil {
+		return nil, ErrNoStatusEvent
+	}
+
+	s.delay.Cancel(orderID)
+	s.delay.Cancel(timeoutJobPrefix + orderID)
+
+	ctx = withEventLog(ctx, event)
+	finalized, err := s.finalize(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if !finalized {
+		// order status was changed by webhook after it was read
+		return nil, models.ErrVersionConflict
+	}
+	slog.InfoContext(ctx, "order is finalized")
+
+	order, err = s.orderStorage.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order, err: %w", err)
+	}
+	return order, nil
+}
+
+// CancelCooldown finalizes order in done status without waiting for the end of cooldown,
+// refund isn't accepted after it
+func (s *WebhookService) CancelCooldown(ctx context.Context, orderID string) (*models.Order, error) {
+	order, err := s.orderStorage.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order, err: %w", err)
+	}
+	if order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.IsFinal || order.Status != models.DoneStatus {
+		return nil, ErrNotInCooldown
+	}
+	return s.Finalize(ctx, orderID)
+}
+
+// finalize marks order and event final, it returns false if order is final or isn't in status of event anymore
+func (s *WebhookService) finalize(ctx context.Context, event *models.Event) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := s.orderStorage.GetOrder(ctx, event.OrderID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get order, err: %w", err)
+		}
+		if order.IsFinal || order.Status != event.OrderStatus {
+			return false, nil
+		}
+
+		order.IsFinal = true
+		order.FinalizedAt = time.Now().UTC()
+		err = s.orderStorage.UpdateOrder(ctx, order)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, models.ErrVersionConflict) || attempt == maxVersionRetries {
+			return false, fmt.Errorf("failed to update order, err: %w", err)
+		}
+	}
+
+	e := *event
+	e.IsFinal = true
+	s.publish(ctx, &e)
+
+	err := s.eventStorage.UpdateEvent(ctx, &e)
+	if err != nil {
+		return true, fmt.Errorf("failed to update event, err: %w", err)
+	}
+	return true, nil
+}
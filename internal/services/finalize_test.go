@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/queue/inmemory"
+	"webhooker/internal/schedule/delay"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_Finalize(t *testing.T) {
+	orderID := "1"
+	testCases := []struct {
+		name      string
+		cooldown  bool
+		order     *models.Order
+		events    []*models.Event
+		finalized *models.Event
+		expErr    error
+	}{
+		{
+			name:      "stuck order",
+			order:     &models.Order{ID: orderID, UserID: "1", Status: models.PendingStatus, Version: 2},
+			events:    []*models.Event{copyEvent(orderCreateEvent), copyEvent(pendingEvent)},
+			finalized: pendingEvent,
+		},
+		{
+			name:      "cooldown is canceled",
+			cooldown:  true,
+			order:     &models.Order{ID: orderID, UserID: "1", Status: models.DoneStatus, Version: 3},
+			events:    []*models.Event{copyEvent(orderCreateEvent), copyEvent(DoneEventNotFinal)},
+			finalized: DoneEventNotFinal,
+		},
+		{
+			name:     "order isn't in cooldown",
+			cooldown: true,
+			order:    &models.Order{ID: orderID, UserID: "1", Status: models.PendingStatus, Version: 2},
+			expErr:   ErrNotInCooldown,
+		},
+		{
+			name:   "order is final",
+			order:  &models.Order{ID: orderID, UserID: "1", Status: models.FailedStatus, IsFinal: true},
+			expErr: models.ErrAfterFinal,
+		},
+		{
+			name:   "order not found",
+			order:  &models.Order{},
+			expErr: ErrOrderNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			order := *tc.order
+			e := apiMock.NewMockEventStorage(ctr)
+			o := apiMock.NewMockOrderStorage(ctr)
+			o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&order, nil).AnyTimes()
+			if tc.events != nil {
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return(tc.events, nil)
+			}
+			if tc.finalized != nil {
+				o.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated *models.Order) error {
+					assert.True(t, updated.IsFinal)
+					assert.Equal(t, tc.order.Status, updated.Status)
+					assert.False(t, updated.FinalizedAt.IsZero())
+					order.IsFinal = true
+					return nil
+				})
+				e.EXPECT().UpdateEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.Event) error {
+					assert.Equal(t, tc.finalized.EventID, event.EventID)
+					assert.True(t, event.IsFinal)
+					return nil
+				})
+			}
+
+			d := delay.NewDelay()
+			defer func() { <-d.Stop() }()
+			// cooldown job of other order isn't canceled
+			d.AddJobFn("2", func() {}, time.Hour)
+			s := NewWebhookService(e, o, inmemory.NewBroker(0), d, nil, Timeouts{}, Settings{})
+
+			var (
+				result *models.Order
+				err    error
+			)
+			if tc.cooldown {
+				result, err = s.CancelCooldown(context.Background(), orderID)
+			} else {
+				result, err = s.Finalize(context.Background(), orderID)
+			}
+			assert.ErrorIs(t, err, tc.expErr)
+			if tc.expErr == nil {
+				assert.True(t, result.IsFinal)
+			}
+			assert.Equal(t, 1, d.Pending())
+		})
+	}
+}
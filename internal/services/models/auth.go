@@ -0,0 +1,48 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type Scope string
+
+const (
+	// ScopeIngest allows to send webhooks
+	ScopeIngest Scope = "ingest"
+	// ScopeRead allows to read orders, events and stats
+	ScopeRead Scope = "read"
+	// ScopeAdmin allows everything
+	ScopeAdmin Scope = "admin"
+)
+
+var Scopes = []Scope{ScopeIngest, ScopeRead, ScopeAdmin}
+
+// Principal is authenticated caller, end user has UserID and can access only own orders
+type Principal struct {
+	Name   string
+	UserID string
+	Scopes []Scope
+	// Issuer is client which issued stream token of principal, limits of issuer are applied to principal
+	Issuer *Principal
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// IsUser is true for end users, services aren't limited by user
+func (p *Principal) IsUser() bool {
+	return p.UserID != ""
+}
+
+// StreamToken allows to read events stream of one order, or of orders of one user, till ExpiresAt
+type StreamToken struct {
+	ID      string
+	OrderID string
+	UserID  string
+	// Issuer is name and user of principal which issued token
+	Issuer       string
+	IssuerUserID string
+	ExpiresAt    time.Time
+}
@@ -16,11 +16,26 @@ type Event struct {
 	UserID      string
 	OrderStatus string
 	IsFinal     bool
+	IsSystem    bool
 	CreateAt    time.Time
 	UpdateAt    time.Time
+	// ReceivedAt is time when webhook was received, zero for events saved before it was tracked
+	ReceivedAt time.Time
+	// Trace is trace context of webhook, it's passed through broker and isn't stored
+	Trace map[string]string
 }
 
 type EventsFilter struct {
 	OrderID *string
 	EventID *string
+	// OrderIDs selects events of several orders, nil doesn't filter by them
+	OrderIDs []string
+}
+
+// EventArchive points to archived events of final order, Key is empty if order had no events
+type EventArchive struct {
+	OrderID     string
+	Key         string
+	EventsCount int
+	ArchivedAt  time.Time
 }
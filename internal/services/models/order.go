@@ -1,8 +1,12 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 const (
+	// CooldownTime is default cooldown after done status
 	CooldownTime = 30 * time.Second
 
 	OrderCreatedStatus = "cool_order_created"
@@ -24,6 +28,15 @@ var StatusPriority = map[string]int{
 	RefundStatus:       7,
 }
 
+// StatusTimeout moves an order to ToStatus if it stays in a non-final status longer than Timeout
+type StatusTimeout struct {
+	Timeout  time.Duration
+	ToStatus string
+}
+
+var ErrVersionConflict = errors.New("order was changed concurrently")
+
+// Order is saved with Version 1, update succeeds only if Version matches stored version and increments it
 type Order struct {
 	ID       string
 	UserID   string
@@ -31,6 +44,10 @@ type Order struct {
 	IsFinal  bool
 	CreateAt time.Time
 	UpdateAt time.Time
+	// FinalizedAt is time when service made order final, it's zero if order isn't final.
+	// UpdateAt is time of provider, it can be long before order became final
+	FinalizedAt time.Time
+	Version     int
 }
 
 type SortBy string
@@ -44,12 +61,27 @@ const (
 	SortDesc SortOrder = "desc"
 )
 
+// OrderFilter conditions are joined by AND, ranges include From and exclude To
 type OrderFilter struct {
-	Status    []string
-	UserID    *string
-	Limit     *int
-	Offset    *int
-	IsFinal   *bool
-	SortBy    *SortBy
-	SortOrder *SortOrder
+	Status      []string
+	UserIDs     []string
+	OrderIDs    []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Limit       *int
+	Offset      *int
+	IsFinal     *bool
+	SortBy      *SortBy
+	SortOrder   *SortOrder
+	After       *OrderCursor
+}
+
+// OrderCursor points to the last order of previous page, OrderID is a tiebreaker for orders with same time
+type OrderCursor struct {
+	SortBy    SortBy
+	SortOrder SortOrder
+	At        time.Time
+	OrderID   string
 }
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type Bucket string
+
+const (
+	BucketNone Bucket = ""
+	BucketHour Bucket = "hour"
+	BucketDay  Bucket = "day"
+)
+
+// StatsFilter selects orders by CreateAt range, From is inclusive and To is exclusive
+type StatsFilter struct {
+	From    *time.Time
+	To      *time.Time
+	UserIDs []string
+	Bucket  Bucket
+}
+
+// OrdersCount is number of orders with status, Bucket is start of time bucket in UTC or zero without bucketing
+type OrdersCount struct {
+	Bucket  time.Time
+	Status  string
+	IsFinal bool
+	Count   int
+}
+
+type StatusCounts struct {
+	Total    int
+	Final    int
+	NonFinal int
+	Statuses map[string]int
+}
+
+type BucketCounts struct {
+	Start time.Time
+	StatusCounts
+}
+
+// Conversion is share of orders which reached From status and then reached To status
+type Conversion struct {
+	From string
+	To   string
+	Rate float64
+}
+
+// Percentiles of time between event and next event of the same order
+type Percentiles struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+type OrderStats struct {
+	Counts StatusCounts
+	// Reached is number of orders which have event with status
+	Reached      map[string]int
+	Conversions  []Conversion
+	TimeInStatus map[string]Percentiles
+	Buckets      []BucketCounts
+}
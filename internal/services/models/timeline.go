@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Timeline is an order with all its events sorted by UpdateAt
+type Timeline struct {
+	Order  *Order
+	Events []*TimelineEvent
+}
+
+type TimelineEvent struct {
+	Event *Event
+	// IsStreamed is true if event is sent to order events stream
+	IsStreamed bool
+	// TimeInStatus is time till next event, nil for the last event
+	TimeInStatus *time.Duration
+}
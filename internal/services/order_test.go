@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 	"webhooker/internal/services/models"
 
 	apiMock "webhooker/internal/storage/api/mocks"
@@ -23,6 +26,8 @@ func Test_GetOrders(t *testing.T) {
 		defSortBy    = models.CreateAt
 		sortOrder    = models.SortDesc
 		defSortOrder = defaultSortOrder
+		from         = time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC)
+		to           = time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)
 	)
 	order := &models.Order{
 		ID: "testID",
@@ -39,16 +44,16 @@ func Test_GetOrders(t *testing.T) {
 			name: "all filters, except isFinal",
 			args: &models.OrderFilter{
 				Status:    statusDone,
-				UserID:    &userID,
+				UserIDs:   []string{userID},
 				Limit:     &limitFive,
 				Offset:    &offset,
 				SortBy:    &sortBy,
 				SortOrder: &sortOrder,
 			},
 			prepare: func(m *apiMock.MockOrderStorage) {
-				m.EXPECT().GetOrders(&models.OrderFilter{
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
 					Status:    statusDone,
-					UserID:    &userID,
+					UserIDs:   []string{userID},
 					Limit:     &limitFive,
 					Offset:    &offset,
 					SortBy:    &sortBy,
@@ -63,7 +68,7 @@ func Test_GetOrders(t *testing.T) {
 				IsFinal: &isFinal,
 			},
 			prepare: func(m *apiMock.MockOrderStorage) {
-				m.EXPECT().GetOrders(&models.OrderFilter{
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
 					IsFinal:   &isFinal,
 					Limit:     &defLimit,
 					Offset:    &defOffset,
@@ -74,9 +79,41 @@ func Test_GetOrders(t *testing.T) {
 			exp: []*models.Order{order},
 		},
 		{
-			name:   "failed. isFinal and status absent",
+			name: "statuses with isFinal and time range",
+			args: &models.OrderFilter{
+				Status:      statusDone,
+				IsFinal:     &isFinal,
+				CreatedFrom: &from,
+				CreatedTo:   &to,
+			},
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					Status:      statusDone,
+					IsFinal:     &isFinal,
+					CreatedFrom: &from,
+					CreatedTo:   &to,
+					Limit:       &defLimit,
+					Offset:      &defOffset,
+					SortBy:      &defSortBy,
+					SortOrder:   &defSortOrder,
+				}).Return([]*models.Order{order}, nil)
+			},
+			exp: []*models.Order{order},
+		},
+		{
+			name:   "failed. no filters",
 			args:   &models.OrderFilter{},
-			expErr: ErrFilterStatus,
+			expErr: ErrFilterRequired,
+		},
+		{
+			name:   "failed. time range end before start",
+			args:   &models.OrderFilter{UpdatedFrom: &to, UpdatedTo: &from},
+			expErr: ErrInvalidTimeRange,
+		},
+		{
+			name:   "failed. too many ids",
+			args:   &models.OrderFilter{OrderIDs: make([]string, maxFilterIDs+1)},
+			expErr: ErrTooManyIDs,
 		},
 	}
 	for _, tc := range testCases {
@@ -93,9 +130,127 @@ func Test_GetOrders(t *testing.T) {
 				orderStorage: orderStorageMock,
 			}
 
-			res, err := s.GetOrders(tc.args)
+			res, err := s.GetOrders(context.Background(), tc.args)
 			assert.Equal(t, tc.exp, res)
 			assert.Equal(t, tc.expErr, err)
 		})
 	}
 }
+
+func Test_GetOrdersByCursor(t *testing.T) {
+	var (
+		isFinal   = true
+		limit     = 2
+		fetch     = 3
+		sortBy    = models.CreateAt
+		sortOrder = models.SortDesc
+		sortAsc   = models.SortAsc
+		first     = &models.Order{ID: "3", CreateAt: time.Date(2022, 10, 10, 11, 30, 32, 0, time.UTC)}
+		second    = &models.Order{ID: "2", CreateAt: time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC)}
+		third     = &models.Order{ID: "1", CreateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC)}
+		cursor    = encodeCursor(newCursor(second, sortBy, sortOrder))
+	)
+
+	testCases := []struct {
+		name      string
+		filter    *models.OrderFilter
+		cursor    string
+		prepare   func(*apiMock.MockOrderStorage)
+		exp       []*models.Order
+		expCursor string
+		expErr    error
+	}{
+		{
+			name:   "first page",
+			filter: &models.OrderFilter{IsFinal: &isFinal, Limit: &limit},
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					IsFinal:   &isFinal,
+					Limit:     &fetch,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+				}).Return([]*models.Order{first, second, third}, nil)
+			},
+			exp:       []*models.Order{first, second},
+			expCursor: cursor,
+		},
+		{
+			name:   "last page",
+			filter: &models.OrderFilter{IsFinal: &isFinal, Limit: &limit},
+			cursor: cursor,
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrders(gomock.Any(), &models.OrderFilter{
+					IsFinal:   &isFinal,
+					Limit:     &fetch,
+					SortBy:    &sortBy,
+					SortOrder: &sortOrder,
+					After: &models.OrderCursor{
+						SortBy:    sortBy,
+						SortOrder: sortOrder,
+						At:        second.CreateAt,
+						OrderID:   second.ID,
+					},
+				}).Return([]*models.Order{third}, nil)
+			},
+			exp: []*models.Order{third},
+		},
+		{
+			name:   "failed. broken cursor",
+			filter: &models.OrderFilter{IsFinal: &isFinal},
+			cursor: "broken",
+			expErr: ErrInvalidCursor,
+		},
+		{
+			name:   "failed. cursor for another sort order",
+			filter: &models.OrderFilter{IsFinal: &isFinal, SortOrder: &sortAsc},
+			cursor: cursor,
+			expErr: ErrInvalidCursor,
+		},
+		{
+			name:   "failed. cursor with offset",
+			filter: &models.OrderFilter{IsFinal: &isFinal, Offset: &limit},
+			expErr: ErrCursorWithOffset,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(orderStorageMock)
+			}
+
+			s := &OrderService{
+				orderStorage: orderStorageMock,
+			}
+
+			res, next, err := s.GetOrdersByCursor(context.Background(), tc.filter, tc.cursor)
+			assert.Equal(t, tc.exp, res)
+			assert.Equal(t, tc.expCursor, next)
+			assert.Equal(t, tc.expErr, err)
+		})
+	}
+}
+
+func Test_GetOrders_Canceled(t *testing.T) {
+	ctr := gomock.NewController(t)
+	defer ctr.Finish()
+
+	isFinal := true
+	ctx, cancel := context.WithCancel(context.Background())
+
+	orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+	orderStorageMock.EXPECT().GetOrders(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, *models.OrderFilter) ([]*models.Order, error) {
+			// client disconnected during query, driver returns own error
+			cancel()
+			return nil, errors.New("pq: canceling statement due to user request")
+		})
+
+	s := NewOrderService(orderStorageMock, nil, Timeouts{GetOrders: time.Second})
+
+	_, err := s.GetOrders(ctx, &models.OrderFilter{IsFinal: &isFinal})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
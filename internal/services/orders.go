@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"time"
 	"webhooker/internal/services/models"
 	"webhooker/internal/storage/api"
 )
@@ -11,30 +13,126 @@ const (
 	defaultOffset    = 0
 	defaultSortBy    = models.CreateAt
 	defaultSortOrder = models.SortDesc
+
+	// maxFilterIDs limits user and order ids in one filter
+	maxFilterIDs = 100
 )
 
 type OrderService struct {
 	orderStorage api.OrderStorage
+	eventStorage api.EventStorage
+	timeouts     Timeouts
 }
 
-func NewOrderService(order api.OrderStorage) *OrderService {
+func NewOrderService(order api.OrderStorage, event api.EventStorage, timeouts Timeouts) *OrderService {
 	return &OrderService{
 		orderStorage: order,
+		eventStorage: event,
+		timeouts:     timeouts,
 	}
 }
 
 var (
-	ErrFilterStatus      = errors.New("provide isFinal or Status")
-	ErrOnlyOneRequired   = errors.New("only isFinal or Status required")
+	ErrFilterRequired    = errors.New("provide isFinal, status, user_id, order_id or time range")
+	ErrInvalidTimeRange  = errors.New("time range start should be before end")
+	ErrTooManyIDs        = errors.New("too many ids in filter")
 	ErrUnsupportedStatus = errors.New("unsupported status")
+	ErrCursorWithOffset  = errors.New("only cursor or offset required")
+	ErrCursorLimit       = errors.New("limit should be positive for cursor pagination")
 )
 
-func (s *OrderService) GetOrders(filter *models.OrderFilter) ([]*models.Order, error) {
-	if filter.IsFinal == nil && filter.Status == nil {
-		return nil, ErrFilterStatus
+func (s *OrderService) GetOrders(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error) {
+	filter, err := ownFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	orderFilter, err := prepareFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.GetOrders)
+	defer cancel()
+
+	orders, err := s.orderStorage.GetOrders(ctx, orderFilter)
+	return orders, contextError(ctx, err)
+}
+
+// GetOrdersByCursor returns orders after cursor and cursor of the next page,
+// empty cursor requests the first page, empty next cursor means the last page
+func (s *OrderService) GetOrdersByCursor(ctx context.Context, filter *models.OrderFilter, cursor string) ([]*models.Order, string, error) {
+	if filter.Offset != nil {
+		return nil, "", ErrCursorWithOffset
+	}
+	filter, err := ownFilter(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	orderFilter, err := prepareFilter(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	orderFilter.Offset = nil
+
+	limit := *orderFilter.Limit
+	if limit <= 0 {
+		return nil, "", ErrCursorLimit
+	}
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// cursor is valid only for the same sorting
+		if after.SortBy != *orderFilter.SortBy || after.SortOrder != *orderFilter.SortOrder {
+			return nil, "", ErrInvalidCursor
+		}
+		orderFilter.After = after
+	}
+
+	// request one more order to know if next page exists
+	fetch := limit + 1
+	orderFilter.Limit = &fetch
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.GetOrders)
+	defer cancel()
+
+	orders, err := s.orderStorage.GetOrders(ctx, orderFilter)
+	if err != nil {
+		return nil, "", contextError(ctx, err)
+	}
+	if len(orders) <= limit {
+		return orders, "", nil
+	}
+
+	orders = orders[:limit]
+	next := newCursor(orders[limit-1], *orderFilter.SortBy, *orderFilter.SortOrder)
+	return orders, encodeCursor(next), nil
+}
+
+// ownFilter limits end users to their own orders, filter of caller isn't changed
+func ownFilter(ctx context.Context, filter *models.OrderFilter) (*models.OrderFilter, error) {
+	userIDs, err := restrictUsers(ctx, filter.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	own := *filter
+	own.UserIDs = userIDs
+	return &own, nil
+}
+
+func prepareFilter(filter *models.OrderFilter) (*models.OrderFilter, error) {
+	// at least one condition is required to not scan all orders
+	if filter.IsFinal == nil && filter.Status == nil && filter.UserIDs == nil && filter.OrderIDs == nil &&
+		filter.CreatedFrom == nil && filter.CreatedTo == nil && filter.UpdatedFrom == nil && filter.UpdatedTo == nil {
+		return nil, ErrFilterRequired
 	}
-	if filter.IsFinal != nil && filter.Status != nil {
-		return nil, ErrOnlyOneRequired
+	if len(filter.UserIDs) > maxFilterIDs || len(filter.OrderIDs) > maxFilterIDs {
+		return nil, ErrTooManyIDs
+	}
+	if !validRange(filter.CreatedFrom, filter.CreatedTo) || !validRange(filter.UpdatedFrom, filter.UpdatedTo) {
+		return nil, ErrInvalidTimeRange
 	}
 	limit := defaultLimit
 	if filter.Limit != nil {
@@ -64,13 +162,22 @@ func (s *OrderService) GetOrders(filter *models.OrderFilter) ([]*models.Order, e
 	}
 
 	orderFilter := &models.OrderFilter{
-		Status:    filter.Status,
-		UserID:    filter.UserID,
-		Limit:     &limit,
-		Offset:    &offset,
-		IsFinal:   filter.IsFinal,
-		SortBy:    &sortBy,
-		SortOrder: &sortOrder,
-	}
-	return s.orderStorage.GetOrders(orderFilter)
+		Status:      filter.Status,
+		UserIDs:     filter.UserIDs,
+		OrderIDs:    filter.OrderIDs,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		UpdatedFrom: filter.UpdatedFrom,
+		UpdatedTo:   filter.UpdatedTo,
+		Limit:       &limit,
+		Offset:      &offset,
+		IsFinal:     filter.IsFinal,
+		SortBy:      &sortBy,
+		SortOrder:   &sortOrder,
+	}
+	return orderFilter, nil
+}
+
+func validRange(from *time.Time, to *time.Time) bool {
+	return from == nil || to == nil || from.Before(*to)
 }
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+const (
+	// maxStatsBuckets limits number of buckets in one request
+	maxStatsBuckets = 24 * 31
+	// maxStatsRange limits number of orders scanned by one request
+	maxStatsRange = 366 * 24 * time.Hour
+)
+
+var (
+	ErrUnsupportedBucket = errors.New("unsupported bucket, use hour or day")
+	ErrBucketRange       = errors.New("bucketing requires from and to with limited number of buckets")
+	ErrStatsRange        = errors.New("stats require from and to not longer than 366 days")
+)
+
+// funnel is lifecycle of successful order, conversion is calculated between neighbour stages
+var funnel = []string{
+	models.OrderCreatedStatus,
+	models.PendingStatus,
+	models.ConfirmedStatus,
+	models.DoneStatus,
+}
+
+type StatsService struct {
+	statsStorage api.StatsStorage
+	timeouts     Timeouts
+}
+
+func NewStatsService(stats api.StatsStorage, timeouts Timeouts) *StatsService {
+	return &StatsService{
+		statsStorage: stats,
+		timeouts:     timeouts,
+	}
+}
+
+func (s *StatsService) GetOrderStats(ctx context.Context, filter *models.StatsFilter) (*models.OrderStats, error) {
+	// end users get stats of their own orders
+	userIDs, err := restrictUsers(ctx, filter.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	own := *filter
+	own.UserIDs = userIDs
+	filter = &own
+
+	if err := validateStatsFilter(filter); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.GetOrders)
+	defer cancel()
+
+	counts, err := s.statsStorage.CountOrders(ctx, filter)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	reached, err := s.statsStorage.CountReached(ctx, filter)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	timeInStatus, err := s.statsStorage.GetStatusPercentiles(ctx, filter)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	stats := &models.OrderStats{
+		Counts:       newStatusCounts(),
+		Reached:      reached,
+		TimeInStatus: timeInStatus,
+	}
+
+	buckets := make(map[time.Time]*models.BucketCounts)
+	for _, c := range counts {
+		addCount(&stats.Counts, c)
+		if filter.Bucket == models.BucketNone {
+			continue
+		}
+		b, ok := buckets[c.Bucket]
+		if !ok {
+			b = &models.BucketCounts{Start: c.Bucket, StatusCounts: newStatusCounts()}
+			buckets[c.Bucket] = b
+		}
+		addCount(&b.StatusCounts, c)
+	}
+	for _, b := range buckets {
+		stats.Buckets = append(stats.Buckets, *b)
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		return stats.Buckets[i].Start.Before(stats.Buckets[j].Start)
+	})
+
+	for i := 0; i+1 < len(funnel); i++ {
+		conversion := models.Conversion{From: funnel[i], To: funnel[i+1]}
+		if reached[funnel[i]] > 0 {
+			conversion.Rate = float64(reached[funnel[i+1]]) / float64(reached[funnel[i]])
+		}
+		stats.Conversions = append(stats.Conversions, conversion)
+	}
+	return stats, nil
+}
+
+func validateStatsFilter(filter *models.StatsFilter) error {
+	if !validRange(filter.From, filter.To) {
+		return ErrInvalidTimeRange
+	}
+	if len(filter.UserIDs) > maxFilterIDs {
+		return ErrTooManyIDs
+	}
+	if filter.From == nil || filter.To == nil || filter.To.Sub(*filter.From) > maxStatsRange {
+		return ErrStatsRange
+	}
+
+	var size time.Duration
+	switch filter.Bucket {
+	case models.BucketNone:
+		return nil
+	case models.BucketHour:
+		size = time.Hour
+	case models.BucketDay:
+		size = 24 * time.Hour
+	default:
+		return ErrUnsupportedBucket
+	}
+	if filter.To.Sub(*filter.From)/size > maxStatsBuckets {
+		return ErrBucketRange
+	}
+	return nil
+}
+
+func newStatusCounts() models.StatusCounts {
+	return models.StatusCounts{Statuses: make(map[string]int)}
+}
+
+func addCount(sc *models.StatusCounts, c *models.OrdersCount) {
+	sc.Total += c.Count
+	if c.IsFinal {
+		sc.Final += c.Count
+	} else {
+		sc.NonFinal += c.Count
+	}
+	sc.Statuses[c.Status] += c.Count
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetOrderStats(t *testing.T) {
+	var (
+		from      = time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC)
+		to        = time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)
+		firstHour = from
+		nextHour  = from.Add(time.Hour)
+		// range is limited to 366 days and hour buckets to 31 days
+		yearLater  = from.AddDate(1, 0, 2)
+		monthLater = from.AddDate(0, 1, 2)
+	)
+
+	testCases := []struct {
+		name    string
+		filter  *models.StatsFilter
+		prepare func(*apiMock.MockStatsStorage)
+		exp     *models.OrderStats
+		expErr  error
+	}{
+		{
+			name:   "with buckets",
+			filter: &models.StatsFilter{From: &from, To: &to, Bucket: models.BucketHour},
+			prepare: func(m *apiMock.MockStatsStorage) {
+				m.EXPECT().CountOrders(gomock.Any(), gomock.Any()).Return([]*models.OrdersCount{
+					{Bucket: nextHour, Status: models.DoneStatus, IsFinal: true, Count: 3},
+					{Bucket: firstHour, Status: models.DoneStatus, IsFinal: true, Count: 1},
+					{Bucket: firstHour, Status: models.PendingStatus, Count: 2},
+				}, nil)
+				m.EXPECT().CountReached(gomock.Any(), gomock.Any()).Return(map[string]int{
+					models.OrderCreatedStatus: 8,
+					models.PendingStatus:      6,
+					models.DoneStatus:         4,
+				}, nil)
+				m.EXPECT().GetStatusPercentiles(gomock.Any(), gomock.Any()).Return(map[string]models.Percentiles{
+					models.PendingStatus: {Count: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second},
+				}, nil)
+			},
+			exp: &models.OrderStats{
+				Counts: models.StatusCounts{
+					Total:    6,
+					Final:    4,
+					NonFinal: 2,
+					Statuses: map[string]int{models.DoneStatus: 4, models.PendingStatus: 2},
+				},
+				Reached: map[string]int{
+					models.OrderCreatedStatus: 8,
+					models.PendingStatus:      6,
+					models.DoneStatus:         4,
+				},
+				Conversions: []models.Conversion{
+					{From: models.OrderCreatedStatus, To: models.PendingStatus, Rate: 0.75},
+					{From: models.PendingStatus, To: models.ConfirmedStatus, Rate: 0},
+					{From: models.ConfirmedStatus, To: models.DoneStatus, Rate: 0},
+				},
+				TimeInStatus: map[string]models.Percentiles{
+					models.PendingStatus: {Count: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second},
+				},
+				Buckets: []models.BucketCounts{
+					{Start: firstHour, StatusCounts: models.StatusCounts{
+						Total: 3, Final: 1, NonFinal: 2,
+						Statuses: map[string]int{models.DoneStatus: 1, models.PendingStatus: 2},
+					}},
+					{Start: nextHour, StatusCounts: models.StatusCounts{
+						Total: 3, Final: 3,
+						Statuses: map[string]int{models.DoneStatus: 3},
+					}},
+				},
+			},
+		},
+		{
+			name:   "failed. unsupported bucket",
+			filter: &models.StatsFilter{From: &from, To: &to, Bucket: "week"},
+			expErr: ErrUnsupportedBucket,
+		},
+		{
+			name:   "failed. without range",
+			filter: &models.StatsFilter{From: &from},
+			expErr: ErrStatsRange,
+		},
+		{
+			name:   "failed. range is too long",
+			filter: &models.StatsFilter{From: &from, To: &yearLater},
+			expErr: ErrStatsRange,
+		},
+		{
+			name:   "failed. too many buckets",
+			filter: &models.StatsFilter{From: &from, To: &monthLater, Bucket: models.BucketHour},
+			expErr: ErrBucketRange,
+		},
+		{
+			name:   "failed. invalid range",
+			filter: &models.StatsFilter{From: &to, To: &from},
+			expErr: ErrInvalidTimeRange,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			statsStorageMock := apiMock.NewMockStatsStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(statsStorageMock)
+			}
+
+			s := NewStatsService(statsStorageMock, Timeouts{})
+
+			res, err := s.GetOrderStats(context.Background(), tc.filter)
+			assert.Equal(t, tc.expErr, err)
+			assert.Equal(t, tc.exp, res)
+		})
+	}
+}
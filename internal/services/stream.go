@@ -3,27 +3,33 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
+	"sync"
 	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
 	"webhooker/internal/queue/inmemory"
 	"webhooker/internal/services/models"
-	"webhooker/internal/storage/posgres"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
+	// default of Settings.StreamWait
 	waitTime = 60 * time.Second
 )
 
 type StreamService struct {
-	eventStorage *posgres.EventStorage
-	orderStorage *posgres.OrderStorage
+	eventStorage api.EventStorage
+	orderStorage api.OrderStorage
 	broker       *inmemory.Broker
 }
 
-func NewStreamService(event *posgres.EventStorage, order *posgres.OrderStorage, broker *inmemory.Broker) *StreamService {
+func NewStreamService(event api.EventStorage, order api.OrderStorage, broker *inmemory.Broker) *StreamService {
 	return &StreamService{
 		eventStorage: event,
 		orderStorage: order,
@@ -32,19 +38,27 @@ func NewStreamService(event *posgres.EventStorage, order *posgres.OrderStorage,
 }
 
 func (s *WebhookService) GetEventStream(ctx context.Context, orderId string) (chan *models.Event, chan bool, chan error) {
-	log.Printf("in GetEventStream\n")
 	eventsCh := make(chan *models.Event)
 	doneCh := make(chan bool)
 	errCh := make(chan error)
 
+	ctx = logging.With(ctx, slog.String(logging.KeyOrderID, orderId))
 	go func() {
 		defer close(eventsCh)
 		defer close(doneCh)
 		defer close(errCh)
 
-		order, err := s.orderStorage.GetOrder(orderId)
+		loadCtx, cancel := withTimeout(ctx, s.timeouts.GetEvents)
+		defer cancel()
+
+		order, err := s.orderStorage.GetOrder(loadCtx, orderId)
 		if err != nil {
-			errCh <- fmt.Errorf("failed to get order %w", err)
+			send(ctx, errCh, fmt.Errorf("failed to get order %w", contextError(loadCtx, err)))
+			return
+		}
+		// end user can't subscribe to order of other user or to order which isn't created yet
+		if !canAccess(ctx, order) {
+			send(ctx, errCh, ErrOrderNotFound)
 			return
 		}
 		// if we don't have order we need order id for event subscription
@@ -52,33 +66,42 @@ func (s *WebhookService) GetEventStream(ctx context.Context, orderId string) (ch
 			order.ID = orderId
 		}
 
-		events, err := s.eventStorage.GetEvents(&models.EventsFilter{OrderID: &orderId})
+		events, err := s.eventStorage.GetEvents(loadCtx, &models.EventsFilter{OrderID: &orderId})
 		if err != nil {
-			errCh <- fmt.Errorf("failed to get events %w", err)
+			send(ctx, errCh, fmt.Errorf("failed to get events %w", contextError(loadCtx, err)))
 			return
 		}
+		cancel()
 
 		es := NewEventStream(order, events, s.broker)
+		ctx = logging.With(ctx, slog.String(logging.KeyStreamID, es.clientID))
 		go es.Stream()
 		defer es.CleanUp()
 
 		for {
 			select {
-			case <-time.After(waitTime):
-				log.Printf("time.After %fs. in Stream\n", waitTime.Seconds())
+			case <-time.After(s.settings.StreamWait):
 				if !es.isActive {
-					doneCh <- true
+					slog.DebugContext(ctx, "close stream of order which isn't created", slog.Duration("wait", s.settings.StreamWait))
+					send(ctx, doneCh, true)
 					return
 				}
 			case message, ok := <-es.eventCh:
 				if ok {
-					eventsCh <- message
+					_, span := tracer.Start(ctx, "EventStream.Deliver", tracing.Link(message.Trace),
+						trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(eventAttributes(message)...))
+					sent := send(ctx, eventsCh, message)
+					span.End()
+					if !sent {
+						return
+					}
+					slog.DebugContext(ctx, "event is sent to stream",
+						slog.String(logging.KeyEventID, message.EventID), slog.String("status", message.OrderStatus))
 				}
 			case <-es.doneCh:
-				doneCh <- true
+				send(ctx, doneCh, true)
 				return
 			case <-ctx.Done():
-				doneCh <- true
 				return
 			}
 		}
@@ -87,6 +110,16 @@ func (s *WebhookService) GetEventStream(ctx context.Context, orderId string) (ch
 	return eventsCh, doneCh, errCh
 }
 
+// send doesn't block after receiver is gone with ctx, handler of stream can return before it
+func send[T any](ctx context.Context, ch chan<- T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 type EventStream struct {
 	broker   *inmemory.Broker
 	order    *models.Order
@@ -95,18 +128,29 @@ type EventStream struct {
 	clientID string
 	eventCh  chan *models.Event
 	doneCh   chan bool
+	// quit stops Stream, channels of stream aren't closed while Stream can send to them
+	quit chan struct{}
+
+	// held events are received but wait for events with previous statuses
+	heldMu  sync.Mutex
+	held    int
+	sent    int
+	cleaned bool
 }
 
 // maybe move in Stream()
 func (es *EventStream) CleanUp() {
-	log.Printf("in CleanUp\n")
-	close(es.eventCh)
-	close(es.doneCh)
+	es.heldMu.Lock()
+	es.cleaned = true
+	metrics.HeldEvents.Sub(float64(es.held))
+	es.held = 0
+	es.heldMu.Unlock()
+
+	close(es.quit)
 	es.broker.UnSubscribe(es.clientID, es.order.ID)
 }
 
 func NewEventStream(order *models.Order, events []*models.Event, br *inmemory.Broker) *EventStream {
-	log.Printf("in NewEventStream\n")
 	return &EventStream{
 		broker:   br,
 		order:    order,
@@ -114,18 +158,16 @@ func NewEventStream(order *models.Order, events []*models.Event, br *inmemory.Br
 		clientID: uuid.NewString(),
 		eventCh:  make(chan *models.Event),
 		doneCh:   make(chan bool),
+		quit:     make(chan struct{}),
 	}
 }
 
 func (es *EventStream) Stream() {
-	log.Printf("in Stream\n")
-
 	// check if we can stream all data from db
 	if es.order.IsFinal && isReadyForFinalStream(es.events) {
-		for _, ev := range es.events {
-			es.eventCh <- ev
+		if es.send(es.events) {
+			es.finish()
 		}
-		es.doneCh <- true
 		return
 	}
 
@@ -137,12 +179,28 @@ func (es *EventStream) Stream() {
 	// sent event that ready
 	eventResolver := eventResolver{events: es.events}
 	eventForStream, _ := eventResolver.resolve()
-	for _, e := range eventForStream {
-		es.eventCh <- e
+	es.updateHeld(len(eventResolver.events), len(eventForStream))
+	if !es.send(eventForStream) {
+		return
 	}
 
 	// subscribe
 	queueCh := es.broker.Subscribe(es.clientID, es.order.ID)
+	if queueCh == nil {
+		// broker is closed on shutdown
+		return
+	}
+	// stream can be cleaned up before subscription
+	select {
+	case <-es.quit:
+		es.broker.UnSubscribe(es.clientID, es.order.ID)
+	default:
+	}
+	// publisher waits for subscriber, channel is read until it's closed by unsubscribe
+	defer func() {
+		for range queueCh {
+		}
+	}()
 	for queueEvent := range queueCh {
 
 		// we receive initial event
@@ -151,16 +209,50 @@ func (es *EventStream) Stream() {
 		}
 		eventResolver.appendEvent(queueEvent)
 		eventForStream, done := eventResolver.resolve()
-		for _, e := range eventForStream {
-			es.eventCh <- e
+		es.updateHeld(len(eventResolver.events), len(eventForStream))
+		if !es.send(eventForStream) {
+			return
 		}
 		if done {
-			es.doneCh <- true
+			es.finish()
 			return
 		}
 	}
 }
 
+// send returns false if stream is cleaned up
+func (es *EventStream) send(events []*models.Event) bool {
+	for _, e := range events {
+		select {
+		case es.eventCh <- e:
+		case <-es.quit:
+			return false
+		}
+	}
+	return true
+}
+
+func (es *EventStream) finish() {
+	select {
+	case es.doneCh <- true:
+	case <-es.quit:
+	}
+}
+
+// updateHeld counts events which are received but not sent yet
+func (es *EventStream) updateHeld(received int, sent int) {
+	es.heldMu.Lock()
+	defer es.heldMu.Unlock()
+
+	if es.cleaned {
+		return
+	}
+	es.sent += sent
+	held := max(received-es.sent, 0)
+	metrics.HeldEvents.Add(float64(held - es.held))
+	es.held = held
+}
+
 type eventResolver struct {
 	lastSendedEvent string
 	events          []*models.Event
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+const (
+	streamTokenPrefix = "v1."
+	// ttl of token if it isn't requested
+	defaultStreamTokenTTL = 5 * time.Minute
+)
+
+var (
+	ErrStreamTokenScope   = errors.New("token should be scoped to order_id or user_id")
+	ErrStreamTokenTTL     = errors.New("ttl should be positive and not longer than max ttl")
+	ErrInvalidStreamToken = errors.New("invalid stream token")
+	ErrStreamTokenExpired = errors.New("stream token expired")
+	ErrStreamTokenRevoked = errors.New("stream token revoked")
+	// token of other client looks absent
+	ErrStreamTokenNotFound = errors.New("stream token not found")
+)
+
+type streamTokenPayload struct {
+	ID      string `json:"jti"`
+	OrderID string `json:"oid,omitempty"`
+	UserID  string `json:"uid,omitempty"`
+	Issuer  string `json:"iss,omitempty"`
+	// IssuerUserID is user which issued token, its limits are applied to streams of token
+	IssuerUserID string `json:"isu,omitempty"`
+	ExpiresAt    int64  `json:"exp"`
+}
+
+// streamTokenIssuer is kept till expiry of token to check who revokes it
+type streamTokenIssuer struct {
+	name    string
+	userID  string
+	expires time.Time
+}
+
+// StreamTokenService issues signed stream tokens for clients which can't send Authorization header.
+// Issuers and revoked tokens are kept in memory till their expiry, so revocation works within one instance
+type StreamTokenService struct {
+	orderStorage api.OrderStorage
+	secret       []byte
+	ttl          time.Duration
+	maxTTL       time.Duration
+	timeouts     Timeouts
+
+	mu      sync.Mutex
+	issuers map[string]streamTokenIssuer
+	revoked map[string]time.Time
+	// streams of token are canceled on revocation
+	streams map[string]map[*context.CancelCauseFunc]struct{}
+}
+
+func NewStreamTokenService(order api.OrderStorage, secret []byte, ttl time.Duration, maxTTL time.Duration, timeouts Timeouts) *StreamTokenService {
+	if ttl == 0 {
+		ttl = defaultStreamTokenTTL
+	}
+	if maxTTL < ttl {
+		maxTTL = ttl
+	}
+	return &StreamTokenService{
+		orderStorage: order,
+		secret:       secret,
+		ttl:          ttl,
+		maxTTL:       maxTTL,
+		timeouts:     timeouts,
+		issuers:      make(map[string]streamTokenIssuer),
+		revoked:      make(map[string]time.Time),
+		streams:      make(map[string]map[*context.CancelCauseFunc]struct{}),
+	}
+}
+
+// IssueToken returns token for streams of one order or of all orders of one user, end users get tokens only for own orders
+func (s *StreamTokenService) IssueToken(ctx context.Context, orderID string, userID string, ttl time.Duration) (string, *models.StreamToken, error) {
+	if (orderID == "") == (userID == "") {
+		return "", nil, ErrStreamTokenScope
+	}
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < 0 || ttl > s.maxTTL {
+		return "", nil, ErrStreamTokenTTL
+	}
+
+	if userID != "" {
+		if _, err := restrictUsers(ctx, []string{userID}); err != nil {
+			return "", nil, err
+		}
+	}
+	principal := PrincipalFromContext(ctx)
+	if orderID != "" && principal != nil && principal.IsUser() {
+		ctx, cancel := withTimeout(ctx, s.timeouts.GetOrders)
+		defer cancel()
+
+		order, err := s.orderStorage.GetOrder(ctx, orderID)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get order %w", contextError(ctx, err))
+		}
+		if order.ID == "" || !canAccess(ctx, order) {
+			return "", nil, ErrOrderNotFound
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token id, err: %w", err)
+	}
+	issuer := streamTokenIssuer{expires: time.Now().Add(ttl).Truncate(time.Second)}
+	if principal != nil {
+		issuer.name, issuer.userID = principal.Name, principal.UserID
+	}
+	token := &models.StreamToken{
+		ID:           base64.RawURLEncoding.EncodeToString(id),
+		OrderID:      orderID,
+		UserID:       userID,
+		Issuer:       issuer.name,
+		IssuerUserID: issuer.userID,
+		ExpiresAt:    issuer.expires,
+	}
+	payload, err := json.Marshal(streamTokenPayload{
+		ID:           token.ID,
+		OrderID:      token.OrderID,
+		UserID:       token.UserID,
+		Issuer:       token.Issuer,
+		IssuerUserID: token.IssuerUserID,
+		ExpiresAt:    token.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal token, err: %w", err)
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	for issuedID, i := range s.issuers {
+		if !now.Before(i.expires) {
+			delete(s.issuers, issuedID)
+		}
+	}
+	s.issuers[token.ID] = issuer
+	s.mu.Unlock()
+	body := streamTokenPrefix + base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + base64.RawURLEncoding.EncodeToString(s.sign(body)), token, nil
+}
+
+// VerifyToken checks signature, expiry and revocation of token
+func (s *StreamTokenService) VerifyToken(value string) (*models.StreamToken, error) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 || !strings.HasPrefix(value, streamTokenPrefix) {
+		return nil, ErrInvalidStreamToken
+	}
+	body, sig := value[:i], value[i+1:]
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(signature, s.sign(body)) {
+		return nil, ErrInvalidStreamToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(body, streamTokenPrefix))
+	if err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+	var p streamTokenPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+
+	token := &models.StreamToken{
+		ID:           p.ID,
+		OrderID:      p.OrderID,
+		UserID:       p.UserID,
+		Issuer:       p.Issuer,
+		IssuerUserID: p.IssuerUserID,
+		ExpiresAt:    time.Unix(p.ExpiresAt, 0),
+	}
+	if !time.Now().Before(token.ExpiresAt) {
+		return nil, ErrStreamTokenExpired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revoked[token.ID]; ok {
+		return nil, ErrStreamTokenRevoked
+	}
+	return token, nil
+}
+
+// RevokeToken rejects token till its expiry and stops its active streams,
+// only issuer of token or admin can revoke it, admin can revoke token issued by other instance
+func (s *StreamTokenService) RevokeToken(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	principal := PrincipalFromContext(ctx)
+	if principal != nil && !principal.HasScope(models.ScopeAdmin) {
+		issuer, ok := s.issuers[id]
+		if !ok || !now.Before(issuer.expires) || issuer.name != principal.Name || issuer.userID != principal.UserID {
+			return ErrStreamTokenNotFound
+		}
+	}
+
+	// token can't live longer than max ttl, expired tokens are rejected anyway
+	for revokedID, until := range s.revoked {
+		if !now.Before(until) {
+			delete(s.revoked, revokedID)
+		}
+	}
+	s.revoked[id] = now.Add(s.maxTTL + time.Second)
+
+	for cancel := range s.streams[id] {
+		(*cancel)(ErrStreamTokenRevoked)
+	}
+	delete(s.streams, id)
+	return nil
+}
+
+// StreamContext is canceled when token expires or is revoked, context.Cause returns the reason
+func (s *StreamTokenService) StreamContext(ctx context.Context, token *models.StreamToken) (context.Context, context.CancelFunc) {
+	ctx, cancelDeadline := context.WithDeadlineCause(ctx, token.ExpiresAt, ErrStreamTokenExpired)
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	s.mu.Lock()
+	if s.streams[token.ID] == nil {
+		s.streams[token.ID] = make(map[*context.CancelCauseFunc]struct{})
+	}
+	s.streams[token.ID][&cancel] = struct{}{}
+	s.mu.Unlock()
+
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.streams[token.ID], &cancel)
+		if len(s.streams[token.ID]) == 0 {
+			delete(s.streams, token.ID)
+		}
+		s.mu.Unlock()
+
+		cancel(context.Canceled)
+		cancelDeadline()
+	}
+}
+
+func (s *StreamTokenService) sign(body string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testStreamSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func Test_StreamToken_Verify(t *testing.T) {
+	s := NewStreamTokenService(nil, testStreamSecret, time.Minute, time.Hour, Timeouts{})
+
+	value, token, err := s.IssueToken(context.Background(), "order1", "", 0)
+	require.Nil(t, err)
+
+	sign := func(p streamTokenPayload) string {
+		payload, err := json.Marshal(p)
+		require.Nil(t, err)
+		body := streamTokenPrefix + base64.RawURLEncoding.EncodeToString(payload)
+		return body + "." + base64.RawURLEncoding.EncodeToString(s.sign(body))
+	}
+	other := NewStreamTokenService(nil, []byte("other-secret-other-secret-other-"), time.Minute, time.Hour, Timeouts{})
+	otherValue, _, err := other.IssueToken(context.Background(), "order1", "", 0)
+	require.Nil(t, err)
+
+	testCases := []struct {
+		name   string
+		token  string
+		exp    *models.StreamToken
+		expErr error
+	}{
+		{
+			name:  "valid token",
+			token: value,
+			exp:   token,
+		},
+		{
+			name:   "signed by other secret",
+			token:  otherValue,
+			expErr: ErrInvalidStreamToken,
+		},
+		{
+			name:   "changed order",
+			token:  changeSignature(sign(streamTokenPayload{ID: token.ID, OrderID: "order2", ExpiresAt: token.ExpiresAt.Unix()}), value),
+			expErr: ErrInvalidStreamToken,
+		},
+		{
+			name:   "expired token",
+			token:  sign(streamTokenPayload{ID: "id1", OrderID: "order1", ExpiresAt: time.Now().Add(-time.Second).Unix()}),
+			expErr: ErrStreamTokenExpired,
+		},
+		{
+			name:   "not a token",
+			token:  "order1",
+			expErr: ErrInvalidStreamToken,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.VerifyToken(tc.token)
+			assert.Equal(t, tc.expErr, err)
+			if tc.exp != nil {
+				assert.Equal(t, tc.exp.ID, got.ID)
+				assert.Equal(t, tc.exp.OrderID, got.OrderID)
+				assert.True(t, tc.exp.ExpiresAt.Equal(got.ExpiresAt))
+			}
+		})
+	}
+}
+
+// changeSignature puts signature of other token to token
+func changeSignature(token string, other string) string {
+	return token[:strings.LastIndexByte(token, '.')] + other[strings.LastIndexByte(other, '.'):]
+}
+
+func Test_StreamToken_Issue(t *testing.T) {
+	user := &models.Principal{Name: "user1", UserID: "user1", Scopes: []models.Scope{models.ScopeRead}}
+
+	testCases := []struct {
+		name      string
+		principal *models.Principal
+		orderID   string
+		userID    string
+		ttl       time.Duration
+		prepare   func(*apiMock.MockOrderStorage)
+		expErr    error
+	}{
+		{
+			name:    "service gets token of any order",
+			orderID: "order1",
+		},
+		{
+			name:      "user gets token of own order",
+			principal: user,
+			orderID:   "order1",
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrder(gomock.Any(), "order1").Return(&models.Order{ID: "order1", UserID: "user1"}, nil)
+			},
+		},
+		{
+			name:      "failed. order of other user",
+			principal: user,
+			orderID:   "order1",
+			prepare: func(m *apiMock.MockOrderStorage) {
+				m.EXPECT().GetOrder(gomock.Any(), "order1").Return(&models.Order{ID: "order1", UserID: "user2"}, nil)
+			},
+			expErr: ErrOrderNotFound,
+		},
+		{
+			name:      "user gets token of own user",
+			principal: user,
+			userID:    "user1",
+		},
+		{
+			name:      "failed. other user",
+			principal: user,
+			userID:    "user2",
+			expErr:    ErrForbidden,
+		},
+		{
+			name:    "failed. order and user",
+			orderID: "order1",
+			userID:  "user1",
+			expErr:  ErrStreamTokenScope,
+		},
+		{
+			name:   "failed. without scope",
+			expErr: ErrStreamTokenScope,
+		},
+		{
+			name:    "failed. ttl is longer than max",
+			orderID: "order1",
+			ttl:     2 * time.Hour,
+			expErr:  ErrStreamTokenTTL,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(orderStorageMock)
+			}
+			s := NewStreamTokenService(orderStorageMock, testStreamSecret, time.Minute, time.Hour, Timeouts{})
+
+			ctx := WithPrincipal(context.Background(), tc.principal)
+			_, _, err := s.IssueToken(ctx, tc.orderID, tc.userID, tc.ttl)
+			assert.Equal(t, tc.expErr, err)
+		})
+	}
+}
+
+func Test_StreamToken_Revoke(t *testing.T) {
+	s := NewStreamTokenService(nil, testStreamSecret, time.Minute, time.Hour, Timeouts{})
+
+	value, token, err := s.IssueToken(context.Background(), "order1", "", 0)
+	require.Nil(t, err)
+
+	ctx, cancel := s.StreamContext(context.Background(), token)
+	defer cancel()
+
+	err = s.RevokeToken(context.Background(), token.ID)
+	require.Nil(t, err)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream isn't canceled")
+	}
+	assert.Equal(t, ErrStreamTokenRevoked, context.Cause(ctx))
+
+	_, err = s.VerifyToken(value)
+	assert.Equal(t, ErrStreamTokenRevoked, err)
+}
+
+func Test_StreamToken_RevokeOther(t *testing.T) {
+	user1 := &models.Principal{Name: "user1", UserID: "user1", Scopes: []models.Scope{models.ScopeRead}}
+	user2 := &models.Principal{Name: "user2", UserID: "user2", Scopes: []models.Scope{models.ScopeRead}}
+	service := &models.Principal{Name: "service", Scopes: []models.Scope{models.ScopeRead}}
+	admin := &models.Principal{Name: "admin", Scopes: []models.Scope{models.ScopeAdmin}}
+
+	testCases := []struct {
+		name    string
+		revoker *models.Principal
+		id      string
+		expErr  error
+	}{
+		{
+			name:    "issuer revokes token",
+			revoker: user1,
+		},
+		{
+			name:    "admin revokes token",
+			revoker: admin,
+		},
+		{
+			name:    "admin revokes token of other instance",
+			revoker: admin,
+			id:      "other",
+		},
+		{
+			name:    "failed. other user",
+			revoker: user2,
+			expErr:  ErrStreamTokenNotFound,
+		},
+		{
+			name:    "failed. service",
+			revoker: service,
+			expErr:  ErrStreamTokenNotFound,
+		},
+		{
+			name:    "failed. unknown token",
+			revoker: user1,
+			id:      "other",
+			expErr:  ErrStreamTokenNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewStreamTokenService(nil, testStreamSecret, time.Minute, time.Hour, Timeouts{})
+
+			value, token, err := s.IssueToken(WithPrincipal(context.Background(), user1), "", "user1", 0)
+			require.Nil(t, err)
+			assert.Equal(t, "user1", token.Issuer)
+
+			id := tc.id
+			if id == "" {
+				id = token.ID
+			}
+			err = s.RevokeToken(WithPrincipal(context.Background(), tc.revoker), id)
+			assert.Equal(t, tc.expErr, err)
+
+			_, err = s.VerifyToken(value)
+			if tc.expErr != nil || tc.id != "" {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, ErrStreamTokenRevoked, err)
+			}
+		})
+	}
+}
+
+func Test_StreamToken_ExpiredMidStream(t *testing.T) {
+	s := NewStreamTokenService(nil, testStreamSecret, time.Minute, time.Hour, Timeouts{})
+
+	token := &models.StreamToken{ID: "id1", OrderID: "order1", ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+	ctx, cancel := s.StreamContext(context.Background(), token)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream isn't canceled")
+	}
+	assert.Equal(t, ErrStreamTokenExpired, context.Cause(ctx))
+
+	cancel()
+	assert.Empty(t, s.streams)
+}
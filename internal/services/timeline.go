@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"webhooker/internal/services/models"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+// GetTimeline returns order with all stored events, including events which aren't streamed
+func (s *OrderService) GetTimeline(ctx context.Context, orderID string) (*models.Timeline, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.GetEvents)
+	defer cancel()
+
+	order, err := s.orderStorage.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %w", contextError(ctx, err))
+	}
+	// order of other user isn't distinguished from absent one
+	if order.ID == "" || !canAccess(ctx, order) {
+		return nil, ErrOrderNotFound
+	}
+
+	events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderID: &orderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events %w", contextError(ctx, err))
+	}
+
+	// EventID is a tiebreaker to keep timeline stable for events with same time
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].UpdateAt.Equal(events[j].UpdateAt) {
+			return events[i].EventID < events[j].EventID
+		}
+		return events[i].UpdateAt.Before(events[j].UpdateAt)
+	})
+
+	streamed := streamedEvents(events)
+
+	timeline := &models.Timeline{
+		Order:  order,
+		Events: make([]*models.TimelineEvent, 0, len(events)),
+	}
+	for i, event := range events {
+		te := &models.TimelineEvent{
+			Event:      event,
+			IsStreamed: streamed[event.EventID],
+		}
+		if i+1 < len(events) {
+			d := events[i+1].UpdateAt.Sub(event.UpdateAt)
+			te.TimeInStatus = &d
+		}
+		timeline.Events = append(timeline.Events, te)
+	}
+	return timeline, nil
+}
+
+// streamedEvents returns ids of events which new stream client receives, same as in GetEventStream
+func streamedEvents(events []*models.Event) map[string]bool {
+	// resolver sorts events, so it gets a copy
+	resolver := eventResolver{events: append([]*models.Event(nil), events...)}
+	forStream, _ := resolver.resolve()
+
+	streamed := make(map[string]bool, len(forStream))
+	for _, e := range forStream {
+		streamed[e.EventID] = true
+	}
+	return streamed
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_GetTimeline(t *testing.T) {
+	var (
+		orderID = "1"
+		order   = &models.Order{
+			ID:       "1",
+			UserID:   "1",
+			Status:   models.DoneStatus,
+			CreateAt: orderCreateEvent.CreateAt,
+			UpdateAt: DoneEventNotFinal.UpdateAt,
+		}
+		duration = func(d time.Duration) *time.Duration { return &d }
+	)
+
+	testCases := []struct {
+		name    string
+		prepare func(*apiMock.MockEventStorage, *apiMock.MockOrderStorage)
+		exp     *models.Timeline
+		expErr  error
+	}{
+		{
+			name: "events out of order with gap",
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).
+					Return([]*models.Event{DoneEventNotFinal, pendingEvent, orderCreateEvent}, nil)
+			},
+			exp: &models.Timeline{
+				Order: order,
+				Events: []*models.TimelineEvent{
+					{Event: orderCreateEvent, IsStreamed: true, TimeInStatus: duration(10)},
+					{Event: pendingEvent, IsStreamed: true, TimeInStatus: duration(20)},
+					// confirmed event is missing, so done isn't streamed yet
+					{Event: DoneEventNotFinal},
+				},
+			},
+		},
+		{
+			name: "final order streams all events",
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).
+					Return([]*models.Event{orderCreateEvent, pendingEvent, confirmedEvent, DoneEventFinal}, nil)
+			},
+			exp: &models.Timeline{
+				Order: order,
+				Events: []*models.TimelineEvent{
+					{Event: orderCreateEvent, IsStreamed: true, TimeInStatus: duration(10)},
+					{Event: pendingEvent, IsStreamed: true, TimeInStatus: duration(5)},
+					{Event: confirmedEvent, IsStreamed: true, TimeInStatus: duration(15)},
+					{Event: DoneEventFinal, IsStreamed: true},
+				},
+			},
+		},
+		{
+			name: "order not found",
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&models.Order{}, nil)
+			},
+			expErr: ErrOrderNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			eventStorageMock := apiMock.NewMockEventStorage(ctr)
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			tc.prepare(eventStorageMock, orderStorageMock)
+
+			s := NewOrderService(orderStorageMock, eventStorageMock, Timeouts{})
+
+			res, err := s.GetTimeline(context.Background(), orderID)
+			assert.Equal(t, tc.expErr, err)
+			assert.Equal(t, tc.exp, res)
+		})
+	}
+}
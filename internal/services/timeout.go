@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/services/models"
+	"webhooker/internal/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	timeoutJobPrefix = "timeout:"
+	timeoutPageSize  = 100
+)
+
+var ErrInvalidTimeout = errors.New("invalid status timeout")
+
+// statuses which can stuck without next webhook
+var timeoutStatuses = map[string]bool{
+	models.OrderCreatedStatus: true,
+	models.PendingStatus:      true,
+	models.ConfirmedStatus:    true,
+}
+
+func ValidateTimeouts(timeouts map[string]models.StatusTimeout) error {
+	for status, timeout := range timeouts {
+		if !timeoutStatuses[status] {
+			return fmt.Errorf("%w: status %s can't have timeout", ErrInvalidTimeout, status)
+		}
+		if timeout.Timeout <= 0 {
+			return fmt.Errorf("%w: timeout for status %s should be positive", ErrInvalidTimeout, status)
+		}
+		toPriority, ok := models.StatusPriority[timeout.ToStatus]
+		if !ok || timeout.ToStatus == models.RefundStatus {
+			return fmt.Errorf("%w: unsupported target status %s", ErrInvalidTimeout, timeout.ToStatus)
+		}
+		if toPriority <= models.StatusPriority[status] {
+			return fmt.Errorf("%w: status %s can't be moved back to %s", ErrInvalidTimeout, status, timeout.ToStatus)
+		}
+	}
+	return nil
+}
+
+// ScheduleTimeouts starts timeouts for orders which were stuck before start
+func (s *WebhookService) ScheduleTimeouts(ctx context.Context) error {
+	if len(s.statusTimeouts) == 0 {
+		return nil
+	}
+
+	statuses := make([]string, 0, len(s.statusTimeouts))
+	for status := range s.statusTimeouts {
+		statuses = append(statuses, status)
+	}
+	orders, err := s.notFinalOrders(ctx, statuses)
+	if err != nil {
+		return fmt.Errorf("failed to get orders for timeouts, err: %w", err)
+	}
+
+	for _, order := range orders {
+		s.scheduleTimeout(ctx, order.ID, order.Status, order.UpdateAt)
+	}
+	slog.InfoContext(ctx, "scheduled timeouts", slog.Int("orders", len(orders)))
+	return nil
+}
+
+// ScheduleCooldowns finalizes orders whose cooldown wasn't finished before restart,
+// jobs aren't run on shutdown, so they are restored from storage
+func (s *WebhookService) ScheduleCooldowns(ctx context.Context) error {
+	orders, err := s.notFinalOrders(ctx, []string{models.DoneStatus})
+	if err != nil {
+		return fmt.Errorf("failed to get orders for cooldown, err: %w", err)
+	}
+
+	for _, order := range orders {
+		orderID := order.ID
+		events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderID: &orderID})
+		if err != nil {
+			return fmt.Errorf("failed to get events for cooldown, err: %w", err)
+		}
+		if event := searchEventByStatus(events, models.DoneStatus); event != nil {
+			s.processWithDelay(ctx, event)
+		}
+	}
+	slog.InfoContext(ctx, "scheduled cooldowns", slog.Int("orders", len(orders)))
+	return nil
+}
+
+// notFinalOrders returns all not final orders in statuses
+func (s *WebhookService) notFinalOrders(ctx context.Context, statuses []string) ([]*models.Order, error) {
+	var (
+		isFinal   = false
+		limit     = timeoutPageSize
+		sortBy    = models.CreateAt
+		sortOrder = models.SortAsc
+		orders    []*models.Order
+	)
+	// collect all orders first, expired jobs can change orders during pagination
+	for offset := 0; ; offset += limit {
+		o := offset
+		page, err := s.orderStorage.GetOrders(ctx, &models.OrderFilter{
+			Status:    statuses,
+			IsFinal:   &isFinal,
+			Limit:     &limit,
+			Offset:    &o,
+			SortBy:    &sortBy,
+			SortOrder: &sortOrder,
+		})
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, page...)
+		if len(page) < limit {
+			break
+		}
+	}
+	return orders, nil
+}
+
+// scheduleTimeout replaces timeout of the order by timeout of new status
+func (s *WebhookService) scheduleTimeout(ctx context.Context, orderID string, status string, updateAt time.Time) {
+	if len(s.statusTimeouts) == 0 {
+		return
+	}
+
+	jobID := timeoutJobPrefix + orderID
+	s.delay.Cancel(jobID)
+
+	timeout, ok := s.statusTimeouts[status]
+	if !ok {
+		return
+	}
+
+	wait := time.Until(updateAt.Add(timeout.Timeout))
+	if wait < 0 {
+		wait = 0
+	}
+	link := tracing.LinkContext(ctx)
+	fn := func() {
+		s.expireOrder(link, orderID, status, timeout.ToStatus)
+	}
+
+	s.delay.AddJobFn(jobID, fn, wait)
+}
+
+func (s *WebhookService) expireOrder(link trace.SpanStartOption, orderID string, status string, toStatus string) {
+	ctx, span := tracer.Start(context.Background(), "job.ExpireOrder", link, trace.WithAttributes(attribute.String("order.id", orderID)))
+	defer span.End()
+	ctx = logging.With(ctx, slog.String(logging.KeyOrderID, orderID))
+	ctx, cancel := withTimeout(ctx, s.timeouts.Job)
+	defer cancel()
+
+	order, err := s.orderStorage.GetOrder(ctx, orderID)
+	if err != nil {
+		span.RecordError(err)
+		slog.ErrorContext(ctx, "failed to get order after timeout", logging.Err(err))
+		return
+	}
+	// order was changed after timeout was scheduled
+	if order.ID == "" || order.IsFinal || order.Status != status {
+		return
+	}
+
+	event := &models.Event{
+		EventID:     uuid.NewString(),
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		OrderStatus: toStatus,
+		IsSystem:    true,
+		CreateAt:    order.CreateAt,
+		UpdateAt:    time.Now().UTC(),
+	}
+	event.ReceivedAt = event.UpdateAt
+	err = s.SaveEvent(ctx, event)
+	if err != nil {
+		span.RecordError(err)
+		slog.ErrorContext(ctx, "failed to save event after timeout", logging.Err(err))
+		return
+	}
+	slog.InfoContext(ctx, "order status is changed after timeout", slog.String("from", status), slog.String("to", toStatus))
+}
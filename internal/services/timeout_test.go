@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"webhooker/internal/queue/inmemory"
+	"webhooker/internal/schedule/delay"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_ValidateTimeouts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		timeouts map[string]models.StatusTimeout
+		expErr   error
+	}{
+		{
+			name: "valid",
+			timeouts: map[string]models.StatusTimeout{
+				models.PendingStatus:   {Timeout: time.Hour, ToStatus: models.FailedStatus},
+				models.ConfirmedStatus: {Timeout: time.Hour, ToStatus: models.FailedStatus},
+			},
+		},
+		{
+			name:     "empty",
+			timeouts: map[string]models.StatusTimeout{},
+		},
+		{
+			name: "final status",
+			timeouts: map[string]models.StatusTimeout{
+				models.FailedStatus: {Timeout: time.Hour, ToStatus: models.RefundStatus},
+			},
+			expErr: ErrInvalidTimeout,
+		},
+		{
+			name: "zero timeout",
+			timeouts: map[string]models.StatusTimeout{
+				models.PendingStatus: {ToStatus: models.FailedStatus},
+			},
+			expErr: ErrInvalidTimeout,
+		},
+		{
+			name: "unknown target",
+			timeouts: map[string]models.StatusTimeout{
+				models.PendingStatus: {Timeout: time.Hour, ToStatus: "unknown"},
+			},
+			expErr: ErrInvalidTimeout,
+		},
+		{
+			name: "target with lower priority",
+			timeouts: map[string]models.StatusTimeout{
+				models.ConfirmedStatus: {Timeout: time.Hour, ToStatus: models.PendingStatus},
+			},
+			expErr: ErrInvalidTimeout,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTimeouts(tc.timeouts)
+			assert.True(t, errors.Is(err, tc.expErr))
+		})
+	}
+}
+
+func Test_ScheduleTimeout(t *testing.T) {
+	d := delay.NewDelay()
+	defer func() { <-d.Stop() }()
+	timeouts := map[string]models.StatusTimeout{
+		models.PendingStatus: {Timeout: time.Hour, ToStatus: models.FailedStatus},
+	}
+	s := NewWebhookService(nil, nil, inmemory.NewBroker(0), d, timeouts, Timeouts{}, Settings{})
+
+	s.scheduleTimeout(context.Background(), "1", models.PendingStatus, time.Now())
+	assert.Equal(t, 1, d.Pending())
+
+	// next status without timeout cancels timeout of previous one
+	s.scheduleTimeout(context.Background(), "1", models.ConfirmedStatus, time.Now())
+	assert.Equal(t, 0, d.Pending())
+}
+
+func Test_ScheduleCooldowns(t *testing.T) {
+	var (
+		orderID   = "1"
+		doneOrder = &models.Order{
+			ID:       orderID,
+			UserID:   "1",
+			Status:   models.DoneStatus,
+			CreateAt: DoneEventNotFinal.CreateAt,
+			UpdateAt: DoneEventNotFinal.UpdateAt,
+		}
+	)
+
+	testCases := []struct {
+		name       string
+		receivedAt time.Time
+		finalized  bool
+	}{
+		{
+			name:       "cooldown is over before restart",
+			receivedAt: time.Now().Add(-time.Hour),
+			finalized:  true,
+		},
+		{
+			name:       "cooldown continues after restart",
+			receivedAt: time.Now(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			done := copyEvent(DoneEventNotFinal)
+			done.ReceivedAt = tc.receivedAt
+			order := *doneOrder
+
+			e := apiMock.NewMockEventStorage(ctr)
+			o := apiMock.NewMockOrderStorage(ctr)
+			o.EXPECT().GetOrders(gomock.Any(), gomock.Any()).Return([]*models.Order{&order}, nil)
+			e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{copyEvent(orderCreateEvent), done}, nil)
+			if tc.finalized {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&order, nil)
+				o.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).Return(nil)
+				e.EXPECT().UpdateEvent(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			d := delay.NewDelay()
+			s := NewWebhookService(e, o, inmemory.NewBroker(0), d, nil, Timeouts{}, Settings{Cooldown: time.Minute})
+			err := s.ScheduleCooldowns(context.Background())
+			require.NoError(t, err)
+
+			if !tc.finalized {
+				assert.Equal(t, 1, d.Pending())
+			}
+			// job without wait is run by timer in own goroutine
+			time.Sleep(20 * time.Millisecond)
+			<-d.Stop()
+			assert.Equal(t, tc.finalized, order.IsFinal)
+		})
+	}
+}
@@ -1,42 +1,111 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/metrics"
 	"webhooker/internal/queue/inmemory"
 	"webhooker/internal/schedule/delay"
 	"webhooker/internal/services/models"
 	"webhooker/internal/storage/api"
-	"webhooker/internal/storage/posgres"
+	"webhooker/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("webhooker/internal/services")
+
+// maxVersionRetries is number of attempts to update order changed concurrently
+const maxVersionRetries = 3
+
 type WebhookService struct {
-	eventStorage *posgres.EventStorage
-	orderStorage api.OrderStorage
-	broker       *inmemory.Broker
-	delay        *delay.Delay
+	eventStorage   api.EventStorage
+	orderStorage   api.OrderStorage
+	broker         *inmemory.Broker
+	delay          *delay.Delay
+	statusTimeouts map[string]models.StatusTimeout
+	timeouts       Timeouts
+	settings       Settings
 }
 
-func NewWebhookService(event *posgres.EventStorage, order api.OrderStorage, broker *inmemory.Broker, delay *delay.Delay) *WebhookService {
+// Settings of webhook processing and streams, zero values are replaced with defaults
+type Settings struct {
+	// events are accepted for Cooldown after done status, then order is finalized
+	Cooldown time.Duration
+	// inactive stream is closed after StreamWait
+	StreamWait time.Duration
+}
+
+func NewWebhookService(event api.EventStorage, order api.OrderStorage, broker *inmemory.Broker, delay *delay.Delay, statusTimeouts map[string]models.StatusTimeout, timeouts Timeouts, settings Settings) *WebhookService {
+	if settings.Cooldown == 0 {
+		settings.Cooldown = models.CooldownTime
+	}
+	if settings.StreamWait == 0 {
+		settings.StreamWait = waitTime
+	}
 	return &WebhookService{
-		eventStorage: event,
-		orderStorage: order,
-		broker:       broker,
-		delay:        delay,
+		eventStorage:   event,
+		orderStorage:   order,
+		broker:         broker,
+		delay:          delay,
+		statusTimeouts: statusTimeouts,
+		timeouts:       timeouts,
+		settings:       settings,
+	}
+}
+
+func (s *WebhookService) SaveEvent(ctx context.Context, event *models.Event) (err error) {
+	start := time.Now()
+	defer func() { metrics.SaveEventDuration.Observe(time.Since(start).Seconds()) }()
+	ctx, span := tracer.Start(ctx, "WebhookService.SaveEvent", trace.WithAttributes(eventAttributes(event)...))
+	defer func() { tracing.End(span, err) }()
+	ctx = withEventLog(ctx, event)
+	ctx, cancel := withTimeout(ctx, s.timeouts.SaveEvent)
+	defer cancel()
+
+	err = s.saveEvent(ctx, event)
+	return contextError(ctx, err)
+}
+
+// publish passes trace context with event, so stream delivery is linked to ingest
+func (s *WebhookService) publish(ctx context.Context, event *models.Event) {
+	ctx, span := tracer.Start(ctx, "Broker.Publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(eventAttributes(event)...))
+	defer span.End()
+
+	event.Trace = tracing.Inject(ctx)
+	s.broker.Publish(event.OrderID, event)
+}
+
+// withEventLog adds ids of event to logs of ctx
+func withEventLog(ctx context.Context, event *models.Event) context.Context {
+	return logging.With(ctx, slog.String(logging.KeyOrderID, event.OrderID), slog.String(logging.KeyEventID, event.EventID))
+}
+
+func eventAttributes(event *models.Event) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("order.id", event.OrderID),
+		attribute.String("event.id", event.EventID),
+		attribute.String("order.status", event.OrderStatus),
 	}
 }
 
-func (s *WebhookService) SaveEvent(event *models.Event) error {
+func (s *WebhookService) saveEvent(ctx context.Context, event *models.Event) error {
 	if _, ok := models.StatusPriority[event.OrderStatus]; !ok {
 		return fmt.Errorf("unsupported status")
 	}
 
-	order, err := s.orderStorage.GetOrder(event.OrderID)
+	order, err := s.orderStorage.GetOrder(ctx, event.OrderID)
 	if err != nil {
 		return err
 	}
 
-	events, err := s.eventStorage.GetEvents(&models.EventsFilter{OrderID: &event.OrderID})
+	events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderID: &event.OrderID})
 	if err != nil {
 		return err
 	}
@@ -47,6 +116,42 @@ func (s *WebhookService) SaveEvent(event *models.Event) error {
 		}
 	}
 
+	err = s.checkEvent(event, order, events)
+	if err != nil {
+		return err
+	}
+
+	// don't interrupt writes if client disconnected, event and order should be saved together
+	writeCtx, cancel := withTimeout(context.WithoutCancel(ctx), s.timeouts.SaveEvent)
+	defer cancel()
+
+	// save event and order in db
+	order, err = s.process(writeCtx, event, order)
+	if err != nil {
+		return err
+	}
+
+	// publish message in queue only after order is updated, so streams don't see rolled back events
+	s.publish(ctx, event)
+
+	if event.OrderStatus == models.DoneStatus {
+		s.processWithDelay(ctx, event)
+	}
+
+	// refund is final, order isn't finalized after cooldown
+	if event.OrderStatus == models.RefundStatus {
+		s.delay.Cancel(event.OrderID)
+	}
+
+	// restart timeout only if event changed order status
+	if models.StatusPriority[event.OrderStatus] >= models.StatusPriority[order.Status] {
+		s.scheduleTimeout(ctx, event.OrderID, event.OrderStatus, event.UpdateAt)
+	}
+	return nil
+}
+
+// checkEvent applies rules of transitions to the state of order, it's run again when order is reloaded after conflict
+func (s *WebhookService) checkEvent(event *models.Event, order *models.Order, events []*models.Event) error {
 	if event.OrderStatus == models.PendingStatus || event.OrderStatus == models.ConfirmedStatus {
 		doneEvent := searchEventByStatus(events, models.DoneStatus)
 		if doneEvent != nil && event.UpdateAt.After(doneEvent.UpdateAt) {
@@ -78,37 +183,80 @@ func (s *WebhookService) SaveEvent(event *models.Event) error {
 		event.IsFinal = true
 	}
 
-	if event.OrderStatus == models.DoneStatus {
-		s.processWithDelay(event)
+	// final order can't be moved to done, e.g. it's failed by timeout
+	if event.OrderStatus == models.DoneStatus && order.IsFinal {
+		return models.ErrAfterFinal
 	}
 
 	if event.OrderStatus == models.RefundStatus {
+		// refund isn't accepted after cooldown is over or cancelled
+		if order.IsFinal {
+			return models.ErrAfterFinal
+		}
 
 		doneEvent := searchEventByStatus(events, models.DoneStatus)
+		if doneEvent == nil || event.UpdateAt.Sub(doneEvent.UpdateAt) >= s.settings.Cooldown {
+			return models.ErrAfterFinal
+		}
+		event.IsFinal = true
+	}
+	return nil
+}
 
-		if doneEvent != nil && event.UpdateAt.Sub(doneEvent.UpdateAt) < models.CooldownTime {
-			event.IsFinal = true
+// process saves event and applies it to order, event is deleted if order can't be updated,
+// it returns state of order which event was applied to
+func (s *WebhookService) process(ctx context.Context, event *models.Event, order *models.Order) (*models.Order, error) {
+	err := s.eventStorage.SaveEvent(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process err %w", err)
+	}
 
-			s.delay.Cancel(event.OrderID)
-		} else {
-			return models.ErrAfterFinal
+	order, err = s.applyWithRetry(ctx, event, order)
+	if err != nil {
+		if delErr := s.eventStorage.DeleteEvent(ctx, event.EventID); delErr != nil {
+			slog.ErrorContext(ctx, "failed to delete event of not updated order", logging.Err(delErr))
 		}
+		return nil, err
 	}
+	return order, nil
+}
 
-	// publish message in queue
-	s.broker.Publish(event.OrderID, event)
+// applyWithRetry applies event to order changed by concurrent webhook or delayed job,
+// order and events are loaded again and rules are checked against new state
+func (s *WebhookService) applyWithRetry(ctx context.Context, event *models.Event, order *models.Order) (*models.Order, error) {
+	for attempt := 1; ; attempt++ {
+		err := s.applyEvent(ctx, event, order)
+		if !errors.Is(err, models.ErrVersionConflict) || attempt == maxVersionRetries {
+			return order, err
+		}
 
-	// save event and order in db
-	s.process(event, order)
-	return nil
+		order, err = s.orderStorage.GetOrder(ctx, event.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		events, err := s.eventStorage.GetEvents(ctx, &models.EventsFilter{OrderID: &event.OrderID})
+		if err != nil {
+			return nil, err
+		}
+		err = s.checkEvent(event, order, withoutEvent(events, event.EventID))
+		if err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (s *WebhookService) process(event *models.Event, order *models.Order) error {
-	err := s.eventStorage.SaveEvent(event)
-	if err != nil {
-		return fmt.Errorf("failed to process err %w", err)
+// withoutEvent excludes already saved event from events of order
+func withoutEvent(events []*models.Event, eventID string) []*models.Event {
+	res := make([]*models.Event, 0, len(events))
+	for _, e := range events {
+		if e.EventID != eventID {
+			res = append(res, e)
+		}
 	}
+	return res
+}
 
+func (s *WebhookService) applyEvent(ctx context.Context, event *models.Event, order *models.Order) error {
 	// update order only if priority of new event higher than event in order
 	// for example we can receive DoneStatus and after than PendingStatus
 
@@ -118,26 +266,33 @@ func (s *WebhookService) process(event *models.Event, order *models.Order) error
 
 	// save new order
 	if order.ID == "" {
-		err := s.orderStorage.SaveOrder(&models.Order{
-			ID:       event.OrderID,
-			UserID:   event.UserID,
-			Status:   event.OrderStatus,
-			IsFinal:  event.IsFinal,
-			CreateAt: event.CreateAt,
-			UpdateAt: event.UpdateAt,
+		err := s.orderStorage.SaveOrder(ctx, &models.Order{
+			ID:          event.OrderID,
+			UserID:      event.UserID,
+			Status:      event.OrderStatus,
+			IsFinal:     event.IsFinal,
+			CreateAt:    event.CreateAt,
+			UpdateAt:    event.UpdateAt,
+			FinalizedAt: finalizedAt(event),
 		})
+		// order was created by concurrent webhook
+		if errors.Is(err, models.ErrAlreadyExist) {
+			return models.ErrVersionConflict
+		}
 		if err != nil {
 			return err
 		}
 	} else {
 		// update existing one
-		err := s.orderStorage.UpdateOrder(&models.Order{
-			ID:       order.ID,
-			UserID:   order.UserID,
-			Status:   event.OrderStatus,
-			IsFinal:  event.IsFinal,
-			CreateAt: order.CreateAt,
-			UpdateAt: event.UpdateAt,
+		err := s.orderStorage.UpdateOrder(ctx, &models.Order{
+			ID:          order.ID,
+			UserID:      order.UserID,
+			Status:      event.OrderStatus,
+			IsFinal:     event.IsFinal,
+			CreateAt:    order.CreateAt,
+			UpdateAt:    event.UpdateAt,
+			FinalizedAt: finalizedAt(event),
+			Version:     order.Version,
 		})
 		if err != nil {
 			return err
@@ -147,35 +302,43 @@ func (s *WebhookService) process(event *models.Event, order *models.Order) error
 	return nil
 }
 
-func (s *WebhookService) processWithDelay(event *models.Event) {
+// finalizedAt is time when final event is received, order of not final event isn't finalized
+func finalizedAt(event *models.Event) time.Time {
+	if !event.IsFinal {
+		return time.Time{}
+	}
+	if !event.ReceivedAt.IsZero() {
+		return event.ReceivedAt
+	}
+	return time.Now().UTC()
+}
+
+func (s *WebhookService) processWithDelay(ctx context.Context, event *models.Event) {
 	e := *event // to avoid data race
+	link := tracing.LinkContext(ctx)
+	// cooldown starts when webhook is received, it's shorter for orders restored after restart
+	wait := s.settings.Cooldown
+	if !e.ReceivedAt.IsZero() {
+		wait = max(time.Until(e.ReceivedAt.Add(s.settings.Cooldown)), 0)
+	}
 	fn := func() {
-		order, err := s.orderStorage.GetOrder(event.OrderID)
-		if err != nil {
-			log.Printf("failed to change order status after cooldown, orderID %s, err:%s", event.OrderID, err.Error())
-			return
-		}
-		if order.IsFinal {
-			return
-		}
-		// publish chinazes in final state
-		event.IsFinal = true
-		s.broker.Publish(event.OrderID, event)
+		ctx, span := tracer.Start(context.Background(), "job.FinalizeOrder", link, trace.WithAttributes(eventAttributes(&e)...))
+		defer span.End()
+		ctx = withEventLog(ctx, &e)
+		ctx, cancel := withTimeout(ctx, s.timeouts.Job)
+		defer cancel()
 
-		// update order in db
-		order.IsFinal = true
-		err = s.orderStorage.UpdateOrder(order)
+		// order is finalized only if it's still in done status, refund can be processed during cooldown
+		finalized, err := s.finalize(ctx, &e)
 		if err != nil {
-			log.Printf("failed to update order after cooldown, orderID %s, err:%s", event.OrderID, err.Error())
+			span.RecordError(err)
+			slog.ErrorContext(ctx, "failed to finalize order after cooldown", logging.Err(err))
+			return
 		}
-
-		// update chinazes to final state
-		err = s.eventStorage.UpdateEvent(event)
-		if err != nil {
-			log.Printf("failed to update event after cooldown, eventID %s, err:%s", event.EventID, err.Error())
+		if finalized {
+			slog.InfoContext(ctx, "order is final after cooldown")
 		}
-		log.Printf("change order_id: %s and event_id: %s to final", event.EventID, event.OrderID)
 	}
 
-	go s.delay.AddJobFn(e.OrderID, fn, models.CooldownTime)
+	s.delay.AddJobFn(e.OrderID, fn, wait)
 }
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"webhooker/internal/queue/inmemory"
+	"webhooker/internal/schedule/delay"
+	"webhooker/internal/services/models"
+
+	apiMock "webhooker/internal/storage/api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_SaveEvent(t *testing.T) {
+	var (
+		orderID = "1"
+		order   = &models.Order{
+			ID:       "1",
+			UserID:   "1",
+			Status:   models.OrderCreatedStatus,
+			CreateAt: orderCreateEvent.CreateAt,
+			UpdateAt: orderCreateEvent.UpdateAt,
+		}
+		finalOrder = &models.Order{
+			ID:       "1",
+			UserID:   "1",
+			Status:   models.ReturnStatus,
+			IsFinal:  true,
+			CreateAt: orderCreateEvent.CreateAt,
+			UpdateAt: returnEvent.UpdateAt,
+		}
+	)
+
+	testCases := []struct {
+		name    string
+		arg     *models.Event
+		prepare func(*apiMock.MockEventStorage, *apiMock.MockOrderStorage)
+		expErr  error
+	}{
+		{
+			name: "new order",
+			arg:  copyEvent(orderCreateEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&models.Order{}, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return(nil, nil)
+				e.EXPECT().SaveEvent(gomock.Any(), orderCreateEvent).Return(nil)
+				o.EXPECT().SaveOrder(gomock.Any(), order).Return(nil)
+			},
+		},
+		{
+			name: "update order",
+			arg:  copyEvent(pendingEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent}, nil)
+				e.EXPECT().SaveEvent(gomock.Any(), pendingEvent).Return(nil)
+				o.EXPECT().UpdateOrder(gomock.Any(), &models.Order{
+					ID:       order.ID,
+					UserID:   order.UserID,
+					Status:   models.PendingStatus,
+					CreateAt: order.CreateAt,
+					UpdateAt: pendingEvent.UpdateAt,
+				}).Return(nil)
+			},
+		},
+		{
+			name: "update order changed concurrently",
+			arg:  copyEvent(pendingEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				changed := *order
+				changed.Version = 2
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent}, nil).Times(2)
+				e.EXPECT().SaveEvent(gomock.Any(), pendingEvent).Return(nil)
+				gomock.InOrder(
+					o.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).Return(models.ErrVersionConflict),
+					o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&changed, nil),
+					o.EXPECT().UpdateOrder(gomock.Any(), &models.Order{
+						ID:       order.ID,
+						UserID:   order.UserID,
+						Status:   models.PendingStatus,
+						CreateAt: order.CreateAt,
+						UpdateAt: pendingEvent.UpdateAt,
+						Version:  2,
+					}).Return(nil),
+				)
+			},
+		},
+		{
+			name: "update order conflict",
+			arg:  copyEvent(pendingEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil).Times(maxVersionRetries)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent}, nil).Times(maxVersionRetries)
+				e.EXPECT().SaveEvent(gomock.Any(), pendingEvent).Return(nil)
+				o.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).Return(models.ErrVersionConflict).Times(maxVersionRetries)
+				// event isn't kept for order which wasn't updated
+				e.EXPECT().DeleteEvent(gomock.Any(), pendingEvent.EventID).Return(nil)
+			},
+			expErr: models.ErrVersionConflict,
+		},
+		{
+			name: "order created concurrently",
+			arg:  copyEvent(orderCreateEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				gomock.InOrder(
+					o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&models.Order{}, nil),
+					o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil),
+				)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return(nil, nil).Times(2)
+				e.EXPECT().SaveEvent(gomock.Any(), orderCreateEvent).Return(nil)
+				o.EXPECT().SaveOrder(gomock.Any(), order).Return(models.ErrAlreadyExist)
+				o.EXPECT().UpdateOrder(gomock.Any(), order).Return(nil)
+			},
+		},
+		{
+			name: "duplicate",
+			arg:  copyEvent(pendingEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, pendingEvent}, nil)
+			},
+			expErr: models.ErrAlreadyExist,
+		},
+		{
+			name: "pending after failed",
+			arg:  copyEvent(pendingEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(finalOrder, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, FailedEvent}, nil)
+			},
+			expErr: models.ErrAfterFinal,
+		},
+		{
+			name: "failed after final",
+			arg:  copyEvent(FailedEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(finalOrder, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, returnEvent}, nil)
+			},
+			expErr: models.ErrAfterFinal,
+		},
+		{
+			name: "done after final",
+			arg:  copyEvent(DoneEventNotFinal),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(finalOrder, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, returnEvent}, nil)
+			},
+			expErr: models.ErrAfterFinal,
+		},
+		{
+			name: "done after order is finalized concurrently",
+			arg:  copyEvent(DoneEventNotFinal),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				gomock.InOrder(
+					o.EXPECT().GetOrder(gomock.Any(), orderID).Return(order, nil),
+					o.EXPECT().GetOrder(gomock.Any(), orderID).Return(finalOrder, nil),
+				)
+				gomock.InOrder(
+					e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent}, nil),
+					e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, returnEvent, DoneEventNotFinal}, nil),
+				)
+				e.EXPECT().SaveEvent(gomock.Any(), DoneEventNotFinal).Return(nil)
+				o.EXPECT().UpdateOrder(gomock.Any(), gomock.Any()).Return(models.ErrVersionConflict)
+				e.EXPECT().DeleteEvent(gomock.Any(), DoneEventNotFinal.EventID).Return(nil)
+			},
+			expErr: models.ErrAfterFinal,
+		},
+		{
+			name: "refund after cooldown is cancelled",
+			arg:  copyEvent(refundEvent),
+			prepare: func(e *apiMock.MockEventStorage, o *apiMock.MockOrderStorage) {
+				done := *finalOrder
+				done.Status = models.DoneStatus
+				o.EXPECT().GetOrder(gomock.Any(), orderID).Return(&done, nil)
+				e.EXPECT().GetEvents(gomock.Any(), &models.EventsFilter{OrderID: &orderID}).Return([]*models.Event{orderCreateEvent, DoneEventFinal}, nil)
+			},
+			expErr: models.ErrAfterFinal,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			eventStorageMock := apiMock.NewMockEventStorage(ctr)
+			orderStorageMock := apiMock.NewMockOrderStorage(ctr)
+			if tc.prepare != nil {
+				tc.prepare(eventStorageMock, orderStorageMock)
+			}
+
+			broker := inmemory.NewBroker(1)
+			published := broker.Subscribe("test", orderID)
+			d := delay.NewDelay()
+			defer func() { <-d.Stop() }()
+			s := NewWebhookService(eventStorageMock, orderStorageMock, broker, d, nil, Timeouts{}, Settings{})
+
+			err := s.SaveEvent(context.Background(), tc.arg)
+			assert.Equal(t, tc.expErr, err)
+			// only events applied to order are published
+			assert.Equal(t, tc.expErr == nil, len(published) == 1)
+		})
+	}
+}
+
+func copyEvent(e *models.Event) *models.Event {
+	c := *e
+	return &c
+}
@@ -1,11 +1,53 @@
 package api
 
-import "webhooker/internal/services/models"
+import (
+	"context"
+	"io"
+	"time"
+	"webhooker/internal/services/models"
+)
 
 //go:generate mockgen -source=api.go -destination=mocks/api_mock.go
+
 type OrderStorage interface {
-	GetOrder(string) (*models.Order, error)
-	GetOrders(*models.OrderFilter) ([]*models.Order, error)
-	SaveOrder(*models.Order) error
-	UpdateOrder(*models.Order) error
+	GetOrder(context.Context, string) (*models.Order, error)
+	GetOrders(context.Context, *models.OrderFilter) ([]*models.Order, error)
+	// IterateOrders streams orders matching filter, stops on first error from callback
+	IterateOrders(context.Context, *models.OrderFilter, func(*models.Order) error) error
+	SaveOrder(context.Context, *models.Order) error
+	UpdateOrder(context.Context, *models.Order) error
+}
+
+type EventStorage interface {
+	SaveEvent(context.Context, *models.Event) error
+	UpdateEvent(context.Context, *models.Event) error
+	// DeleteEvent removes event by id, it's used to roll back event when order can't be updated
+	DeleteEvent(context.Context, string) error
+	GetEvents(context.Context, *models.EventsFilter) ([]*models.Event, error)
+}
+
+type ArchiveStorage interface {
+	// GetArchive returns empty archive if events of order aren't archived
+	GetArchive(context.Context, string) (*models.EventArchive, error)
+	// GetArchives returns archives of orders which have them
+	GetArchives(context.Context, []string) ([]*models.EventArchive, error)
+	// GetArchiveCandidates returns ids of orders finalized before time and not archived yet
+	GetArchiveCandidates(context.Context, time.Time, int) ([]string, error)
+	// SaveArchive saves archive and removes archived events by ids from events storage
+	SaveArchive(context.Context, *models.EventArchive, []string) error
+}
+
+type StatsStorage interface {
+	// CountOrders groups orders by time bucket, status and final flag
+	CountOrders(context.Context, *models.StatsFilter) ([]*models.OrdersCount, error)
+	// CountReached returns number of orders which have event with status
+	CountReached(context.Context, *models.StatsFilter) (map[string]int, error)
+	// GetStatusPercentiles returns percentiles of time till next event of order by status, archived events are included
+	GetStatusPercentiles(context.Context, *models.StatsFilter) (map[string]models.Percentiles, error)
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
 }
@@ -10,7 +10,10 @@
 package mock_api
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 	models "webhooker/internal/services/models"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,59 +43,369 @@ func (m *MockOrderStorage) EXPECT() *MockOrderStorageMockRecorder {
 }
 
 // GetOrder mocks base method.
-func (m *MockOrderStorage) GetOrder(arg0 string) (*models.Order, error) {
+func (m *MockOrderStorage) GetOrder(arg0 context.Context, arg1 string) (*models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrder", arg0)
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1)
 	ret0, _ := ret[0].(*models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrder indicates an expected call of GetOrder.
-func (mr *MockOrderStorageMockRecorder) GetOrder(arg0 any) *gomock.Call {
+func (mr *MockOrderStorageMockRecorder) GetOrder(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrderStorage)(nil).GetOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrderStorage)(nil).GetOrder), arg0, arg1)
 }
 
 // GetOrders mocks base method.
-func (m *MockOrderStorage) GetOrders(arg0 *models.OrderFilter) ([]*models.Order, error) {
+func (m *MockOrderStorage) GetOrders(arg0 context.Context, arg1 *models.OrderFilter) ([]*models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrders", arg0)
+	ret := m.ctrl.Call(m, "GetOrders", arg0, arg1)
 	ret0, _ := ret[0].([]*models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrders indicates an expected call of GetOrders.
-func (mr *MockOrderStorageMockRecorder) GetOrders(arg0 any) *gomock.Call {
+func (mr *MockOrderStorageMockRecorder) GetOrders(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderStorage)(nil).GetOrders), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderStorage)(nil).GetOrders), arg0, arg1)
+}
+
+// IterateOrders mocks base method.
+func (m *MockOrderStorage) IterateOrders(arg0 context.Context, arg1 *models.OrderFilter, arg2 func(*models.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateOrders", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateOrders indicates an expected call of IterateOrders.
+func (mr *MockOrderStorageMockRecorder) IterateOrders(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateOrders", reflect.TypeOf((*MockOrderStorage)(nil).IterateOrders), arg0, arg1, arg2)
 }
 
 // SaveOrder mocks base method.
-func (m *MockOrderStorage) SaveOrder(arg0 *models.Order) error {
+func (m *MockOrderStorage) SaveOrder(arg0 context.Context, arg1 *models.Order) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveOrder", arg0)
+	ret := m.ctrl.Call(m, "SaveOrder", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveOrder indicates an expected call of SaveOrder.
-func (mr *MockOrderStorageMockRecorder) SaveOrder(arg0 any) *gomock.Call {
+func (mr *MockOrderStorageMockRecorder) SaveOrder(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrder", reflect.TypeOf((*MockOrderStorage)(nil).SaveOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrder", reflect.TypeOf((*MockOrderStorage)(nil).SaveOrder), arg0, arg1)
 }
 
 // UpdateOrder mocks base method.
-func (m *MockOrderStorage) UpdateOrder(arg0 *models.Order) error {
+func (m *MockOrderStorage) UpdateOrder(arg0 context.Context, arg1 *models.Order) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOrder", arg0)
+	ret := m.ctrl.Call(m, "UpdateOrder", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateOrder indicates an expected call of UpdateOrder.
-func (mr *MockOrderStorageMockRecorder) UpdateOrder(arg0 any) *gomock.Call {
+func (mr *MockOrderStorageMockRecorder) UpdateOrder(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockOrderStorage)(nil).UpdateOrder), arg0, arg1)
+}
+
+// MockEventStorage is a mock of EventStorage interface.
+type MockEventStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventStorageMockRecorder
+}
+
+// MockEventStorageMockRecorder is the mock recorder for MockEventStorage.
+type MockEventStorageMockRecorder struct {
+	mock *MockEventStorage
+}
+
+// NewMockEventStorage creates a new mock instance.
+func NewMockEventStorage(ctrl *gomock.Controller) *MockEventStorage {
+	mock := &MockEventStorage{ctrl: ctrl}
+	mock.recorder = &MockEventStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventStorage) EXPECT() *MockEventStorageMockRecorder {
+	return m.recorder
+}
+
+// DeleteEvent mocks base method.
+func (m *MockEventStorage) DeleteEvent(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEvent indicates an expected call of DeleteEvent.
+func (mr *MockEventStorageMockRecorder) DeleteEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvent", reflect.TypeOf((*MockEventStorage)(nil).DeleteEvent), arg0, arg1)
+}
+
+// GetEvents mocks base method.
+func (m *MockEventStorage) GetEvents(arg0 context.Context, arg1 *models.EventsFilter) ([]*models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockEventStorageMockRecorder) GetEvents(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockEventStorage)(nil).GetEvents), arg0, arg1)
+}
+
+// SaveEvent mocks base method.
+func (m *MockEventStorage) SaveEvent(arg0 context.Context, arg1 *models.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvent indicates an expected call of SaveEvent.
+func (mr *MockEventStorageMockRecorder) SaveEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventStorage)(nil).SaveEvent), arg0, arg1)
+}
+
+// UpdateEvent mocks base method.
+func (m *MockEventStorage) UpdateEvent(arg0 context.Context, arg1 *models.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEvent indicates an expected call of UpdateEvent.
+func (mr *MockEventStorageMockRecorder) UpdateEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEvent", reflect.TypeOf((*MockEventStorage)(nil).UpdateEvent), arg0, arg1)
+}
+
+// MockArchiveStorage is a mock of ArchiveStorage interface.
+type MockArchiveStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockArchiveStorageMockRecorder
+}
+
+// MockArchiveStorageMockRecorder is the mock recorder for MockArchiveStorage.
+type MockArchiveStorageMockRecorder struct {
+	mock *MockArchiveStorage
+}
+
+// NewMockArchiveStorage creates a new mock instance.
+func NewMockArchiveStorage(ctrl *gomock.Controller) *MockArchiveStorage {
+	mock := &MockArchiveStorage{ctrl: ctrl}
+	mock.recorder = &MockArchiveStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArchiveStorage) EXPECT() *MockArchiveStorageMockRecorder {
+	return m.recorder
+}
+
+// GetArchive mocks base method.
+func (m *MockArchiveStorage) GetArchive(arg0 context.Context, arg1 string) (*models.EventArchive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchive", arg0, arg1)
+	ret0, _ := ret[0].(*models.EventArchive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchive indicates an expected call of GetArchive.
+func (mr *MockArchiveStorageMockRecorder) GetArchive(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchive", reflect.TypeOf((*MockArchiveStorage)(nil).GetArchive), arg0, arg1)
+}
+
+// GetArchives mocks base method.
+func (m *MockArchiveStorage) GetArchives(arg0 context.Context, arg1 []string) ([]*models.EventArchive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchives", arg0, arg1)
+	ret0, _ := ret[0].([]*models.EventArchive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchives indicates an expected call of GetArchives.
+func (mr *MockArchiveStorageMockRecorder) GetArchives(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchives", reflect.TypeOf((*MockArchiveStorage)(nil).GetArchives), arg0, arg1)
+}
+
+// GetArchiveCandidates mocks base method.
+func (m *MockArchiveStorage) GetArchiveCandidates(arg0 context.Context, arg1 time.Time, arg2 int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchiveCandidates", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchiveCandidates indicates an expected call of GetArchiveCandidates.
+func (mr *MockArchiveStorageMockRecorder) GetArchiveCandidates(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchiveCandidates", reflect.TypeOf((*MockArchiveStorage)(nil).GetArchiveCandidates), arg0, arg1, arg2)
+}
+
+// SaveArchive mocks base method.
+func (m *MockArchiveStorage) SaveArchive(arg0 context.Context, arg1 *models.EventArchive, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveArchive", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveArchive indicates an expected call of SaveArchive.
+func (mr *MockArchiveStorageMockRecorder) SaveArchive(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveArchive", reflect.TypeOf((*MockArchiveStorage)(nil).SaveArchive), arg0, arg1, arg2)
+}
+
+// MockStatsStorage is a mock of StatsStorage interface.
+type MockStatsStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsStorageMockRecorder
+}
+
+// MockStatsStorageMockRecorder is the mock recorder for MockStatsStorage.
+type MockStatsStorageMockRecorder struct {
+	mock *MockStatsStorage
+}
+
+// NewMockStatsStorage creates a new mock instance.
+func NewMockStatsStorage(ctrl *gomock.Controller) *MockStatsStorage {
+	mock := &MockStatsStorage{ctrl: ctrl}
+	mock.recorder = &MockStatsStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsStorage) EXPECT() *MockStatsStorageMockRecorder {
+	return m.recorder
+}
+
+// CountOrders mocks base method.
+func (m *MockStatsStorage) CountOrders(arg0 context.Context, arg1 *models.StatsFilter) ([]*models.OrdersCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOrders", arg0, arg1)
+	ret0, _ := ret[0].([]*models.OrdersCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOrders indicates an expected call of CountOrders.
+func (mr *MockStatsStorageMockRecorder) CountOrders(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOrders", reflect.TypeOf((*MockStatsStorage)(nil).CountOrders), arg0, arg1)
+}
+
+// CountReached mocks base method.
+func (m *MockStatsStorage) CountReached(arg0 context.Context, arg1 *models.StatsFilter) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountReached", arg0, arg1)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountReached indicates an expected call of CountReached.
+func (mr *MockStatsStorageMockRecorder) CountReached(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountReached", reflect.TypeOf((*MockStatsStorage)(nil).CountReached), arg0, arg1)
+}
+
+// GetStatusPercentiles mocks base method.
+func (m *MockStatsStorage) GetStatusPercentiles(arg0 context.Context, arg1 *models.StatsFilter) (map[string]models.Percentiles, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusPercentiles", arg0, arg1)
+	ret0, _ := ret[0].(map[string]models.Percentiles)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusPercentiles indicates an expected call of GetStatusPercentiles.
+func (mr *MockStatsStorageMockRecorder) GetStatusPercentiles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusPercentiles", reflect.TypeOf((*MockStatsStorage)(nil).GetStatusPercentiles), arg0, arg1)
+}
+
+// MockBlobStore is a mock of BlobStore interface.
+type MockBlobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlobStoreMockRecorder
+}
+
+// MockBlobStoreMockRecorder is the mock recorder for MockBlobStore.
+type MockBlobStoreMockRecorder struct {
+	mock *MockBlobStore
+}
+
+// NewMockBlobStore creates a new mock instance.
+func NewMockBlobStore(ctrl *gomock.Controller) *MockBlobStore {
+	mock := &MockBlobStore{ctrl: ctrl}
+	mock.recorder = &MockBlobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlobStore) EXPECT() *MockBlobStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBlobStoreMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBlobStore)(nil).Delete), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBlobStoreMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlobStore)(nil).Get), ctx, key)
+}
+
+// Put mocks base method.
+func (m *MockBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBlobStoreMockRecorder) Put(ctx, key, r any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockOrderStorage)(nil).UpdateOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBlobStore)(nil).Put), ctx, key, r)
 }
@@ -0,0 +1,123 @@
+// Package archive moves events of long finished orders from events storage to blob store
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"time"
+	"webhooker/internal/logging"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+const keyPrefix = "events/"
+
+type Archiver struct {
+	events    api.EventStorage
+	archives  api.ArchiveStorage
+	blob      api.BlobStore
+	retention time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+// NewArchiver archives events of orders which are final for longer than retention
+func NewArchiver(events api.EventStorage, archives api.ArchiveStorage, blob api.BlobStore, retention time.Duration, batchSize int) *Archiver {
+	return &Archiver{
+		events:    events,
+		archives:  archives,
+		blob:      blob,
+		retention: retention,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Run archives orders every interval until ctx is done
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := a.ArchiveOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to archive events", logging.Err(err))
+		}
+		if count > 0 {
+			slog.InfoContext(ctx, "archived events", slog.Int("orders", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveOnce archives all current candidates and returns number of archived orders
+func (a *Archiver) ArchiveOnce(ctx context.Context) (int, error) {
+	before := a.now().Add(-a.retention)
+	count := 0
+	for {
+		ids, err := a.archives.GetArchiveCandidates(ctx, before, a.batchSize)
+		if err != nil {
+			return count, err
+		}
+		for _, id := range ids {
+			if err := a.archiveOrder(ctx, id); err != nil {
+				return count, fmt.Errorf("failed to archive order %s: %w", id, err)
+			}
+			count++
+		}
+		if len(ids) < a.batchSize {
+			return count, nil
+		}
+	}
+}
+
+// archiveOrder writes blob before removing events, so failure on any step doesn't lose events
+func (a *Archiver) archiveOrder(ctx context.Context, orderID string) error {
+	events, err := a.events.GetEvents(ctx, &models.EventsFilter{OrderID: &orderID})
+	if err != nil {
+		return err
+	}
+
+	archive := &models.EventArchive{
+		OrderID:     orderID,
+		EventsCount: len(events),
+		ArchivedAt:  a.now(),
+	}
+
+	// order without events is marked as archived to not check it again
+	ids := make([]string, 0, len(events))
+	if len(events) > 0 {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].UpdateAt.Before(events[j].UpdateAt)
+		})
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, events); err != nil {
+			return err
+		}
+		archive.Key = keyPrefix + url.PathEscape(orderID) + ".ndjson.gz"
+		if err := a.blob.Put(ctx, archive.Key, &buf); err != nil {
+			return err
+		}
+		for _, event := range events {
+			ids = append(ids, event.EventID)
+		}
+	}
+
+	err = a.archives.SaveArchive(ctx, archive, ids)
+	if errors.Is(err, models.ErrAlreadyExist) {
+		// archived by other instance
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/blob"
+	"webhooker/internal/storage/inmemory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ArchiveOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 11, 10, 11, 30, 30, 0, time.UTC)
+
+	orders, hot := &inmemory.OrderStorage{}, &inmemory.EventStorage{}
+	archives := inmemory.NewArchiveStorage(orders, hot)
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.Nil(t, err)
+
+	oldOrder := &models.Order{ID: "old/1", UserID: "user1", Status: models.DoneStatus, IsFinal: true, UpdateAt: now.Add(-48 * time.Hour), FinalizedAt: now.Add(-48 * time.Hour)}
+	// provider time is old, but order was finalized recently
+	freshOrder := &models.Order{ID: "fresh", UserID: "user1", Status: models.DoneStatus, IsFinal: true, UpdateAt: now.Add(-48 * time.Hour), FinalizedAt: now.Add(-time.Hour)}
+	openOrder := &models.Order{ID: "open", UserID: "user1", Status: models.PendingStatus, UpdateAt: now.Add(-48 * time.Hour)}
+	emptyOrder := &models.Order{ID: "empty", UserID: "user1", Status: models.FailedStatus, IsFinal: true, UpdateAt: now.Add(-48 * time.Hour), FinalizedAt: now.Add(-48 * time.Hour)}
+	for _, o := range []*models.Order{oldOrder, freshOrder, openOrder, emptyOrder} {
+		require.Nil(t, orders.SaveOrder(ctx, o))
+	}
+
+	oldEvents := []*models.Event{
+		{EventID: "1", OrderID: oldOrder.ID, UserID: "user1", OrderStatus: models.OrderCreatedStatus, CreateAt: now.Add(-50 * time.Hour), UpdateAt: now.Add(-50 * time.Hour), ReceivedAt: now.Add(-50 * time.Hour)},
+		{EventID: "2", OrderID: oldOrder.ID, UserID: "user1", OrderStatus: models.DoneStatus, IsFinal: true, IsSystem: true, CreateAt: now.Add(-50 * time.Hour), UpdateAt: now.Add(-48 * time.Hour)},
+	}
+	freshEvent := &models.Event{EventID: "3", OrderID: freshOrder.ID, UserID: "user1", OrderStatus: models.DoneStatus, CreateAt: now, UpdateAt: now}
+	for _, e := range append(oldEvents, freshEvent) {
+		require.Nil(t, hot.SaveEvent(ctx, e))
+	}
+
+	archiver := NewArchiver(hot, archives, store, 24*time.Hour, 1)
+	archiver.now = func() time.Time { return now }
+
+	count, err := archiver.ArchiveOnce(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// events are removed from events storage
+	events, err := hot.GetEvents(ctx, &models.EventsFilter{OrderID: &oldOrder.ID})
+	require.Nil(t, err)
+	assert.Empty(t, events)
+
+	archive, err := archives.GetArchive(ctx, oldOrder.ID)
+	require.Nil(t, err)
+	assert.Equal(t, &models.EventArchive{OrderID: oldOrder.ID, Key: "events/old%2F1.ndjson.gz", EventsCount: 2, ArchivedAt: now}, archive)
+
+	archive, err = archives.GetArchive(ctx, emptyOrder.ID)
+	require.Nil(t, err)
+	assert.Equal(t, &models.EventArchive{OrderID: emptyOrder.ID, ArchivedAt: now}, archive)
+
+	// archived events are rehydrated together with events saved after archive
+	lateEvent := &models.Event{EventID: "4", OrderID: oldOrder.ID, UserID: "user1", OrderStatus: models.PendingStatus, CreateAt: now, UpdateAt: now}
+	require.Nil(t, hot.SaveEvent(ctx, lateEvent))
+
+	storage := NewEventStorage(hot, archives, store)
+	events, err = storage.GetEvents(ctx, &models.EventsFilter{OrderID: &oldOrder.ID})
+	require.Nil(t, err)
+	assertEvents(t, append(oldEvents, lateEvent), events)
+
+	events, err = storage.GetEvents(ctx, &models.EventsFilter{OrderID: &oldOrder.ID, EventID: &oldEvents[1].EventID})
+	require.Nil(t, err)
+	assertEvents(t, oldEvents[1:], events)
+
+	events, err = storage.GetEvents(ctx, &models.EventsFilter{OrderID: &freshOrder.ID})
+	require.Nil(t, err)
+	assertEvents(t, []*models.Event{freshEvent}, events)
+
+	count, err = archiver.ArchiveOnce(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+// json keeps monotonic clock out of time, so time is compared with Equal
+func assertEvents(t *testing.T, exp []*models.Event, res []*models.Event) {
+	t.Helper()
+	require.Len(t, res, len(exp))
+	for i := range exp {
+		e, r := *exp[i], *res[i]
+		assert.True(t, e.CreateAt.Equal(r.CreateAt))
+		assert.True(t, e.UpdateAt.Equal(r.UpdateAt))
+		assert.True(t, e.ReceivedAt.Equal(r.ReceivedAt))
+		e.CreateAt, e.UpdateAt, e.ReceivedAt = time.Time{}, time.Time{}, time.Time{}
+		r.CreateAt, r.UpdateAt, r.ReceivedAt = time.Time{}, time.Time{}, time.Time{}
+		assert.Equal(t, e, r)
+	}
+}
@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"webhooker/internal/services/models"
+)
+
+// eventRecord is one line of archive, json names are part of archive format and shouldn't be changed
+type eventRecord struct {
+	EventID     string     `json:"event_id"`
+	OrderID     string     `json:"order_id"`
+	UserID      string     `json:"user_id"`
+	OrderStatus string     `json:"order_status"`
+	IsFinal     bool       `json:"is_final"`
+	IsSystem    bool       `json:"is_system"`
+	CreateAt    time.Time  `json:"created_at"`
+	UpdateAt    time.Time  `json:"updated_at"`
+	ReceivedAt  *time.Time `json:"received_at,omitempty"`
+}
+
+// Encode writes events as gzip compressed NDJSON
+func Encode(w io.Writer, events []*models.Event) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	for _, event := range events {
+		record := eventRecord{
+			EventID:     event.EventID,
+			OrderID:     event.OrderID,
+			UserID:      event.UserID,
+			OrderStatus: event.OrderStatus,
+			IsFinal:     event.IsFinal,
+			IsSystem:    event.IsSystem,
+			CreateAt:    event.CreateAt,
+			UpdateAt:    event.UpdateAt,
+		}
+		if !event.ReceivedAt.IsZero() {
+			record.ReceivedAt = &event.ReceivedAt
+		}
+		if err := enc.Encode(record); err != nil {
+			zw.Close()
+			return fmt.Errorf("failed to encode event %s: %w", event.EventID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress events: %w", err)
+	}
+	return nil
+}
+
+// Decode reads events written by Encode
+func Decode(r io.Reader) ([]*models.Event, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress events: %w", err)
+	}
+	defer zr.Close()
+
+	var events []*models.Event
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var record eventRecord
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+		event := &models.Event{
+			EventID:     record.EventID,
+			OrderID:     record.OrderID,
+			UserID:      record.UserID,
+			OrderStatus: record.OrderStatus,
+			IsFinal:     record.IsFinal,
+			IsSystem:    record.IsSystem,
+			CreateAt:    record.CreateAt,
+			UpdateAt:    record.UpdateAt,
+		}
+		if record.ReceivedAt != nil {
+			event.ReceivedAt = *record.ReceivedAt
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
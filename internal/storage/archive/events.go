@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+// EventStorage reads archived events of order back from blob store,
+// so callers see full order history regardless of where events are kept
+type EventStorage struct {
+	events   api.EventStorage
+	archives api.ArchiveStorage
+	blob     api.BlobStore
+}
+
+func NewEventStorage(events api.EventStorage, archives api.ArchiveStorage, blob api.BlobStore) api.EventStorage {
+	return &EventStorage{
+		events:   events,
+		archives: archives,
+		blob:     blob,
+	}
+}
+
+func (e *EventStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	return e.events.SaveEvent(ctx, event)
+}
+
+func (e *EventStorage) UpdateEvent(ctx context.Context, event *models.Event) error {
+	return e.events.UpdateEvent(ctx, event)
+}
+
+func (e *EventStorage) DeleteEvent(ctx context.Context, eventID string) error {
+	return e.events.DeleteEvent(ctx, eventID)
+}
+
+// GetEvents rehydrates archives only when filter has order ids, lookup only by event id reads events storage
+func (e *EventStorage) GetEvents(ctx context.Context, filter *models.EventsFilter) ([]*models.Event, error) {
+	events, err := e.events.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := filter.OrderIDs
+	if filter.OrderID != nil {
+		orderIDs = []string{*filter.OrderID}
+	}
+	if orderIDs == nil {
+		return events, nil
+	}
+
+	archives, err := e.archives.GetArchives(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return events, nil
+	}
+
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		seen[event.EventID] = true
+	}
+	var result []*models.Event
+	for _, archive := range archives {
+		if archive.Key == "" {
+			continue
+		}
+		archived, err := e.load(ctx, archive.Key)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range archived {
+			if seen[event.EventID] || (filter.EventID != nil && event.EventID != *filter.EventID) {
+				continue
+			}
+			result = append(result, event)
+		}
+	}
+	return append(result, events...), nil
+}
+
+func (e *EventStorage) load(ctx context.Context, key string) ([]*models.Event, error) {
+	r, err := e.blob.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", key, err)
+	}
+	defer r.Close()
+
+	return Decode(r)
+}
@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.Nil(t, err)
+
+	require.Nil(t, store.Put(ctx, "events/1.ndjson.gz", strings.NewReader("data")))
+
+	r, err := store.Get(ctx, "events/1.ndjson.gz")
+	require.Nil(t, err)
+	data, err := io.ReadAll(r)
+	require.Nil(t, err)
+	require.Nil(t, r.Close())
+	assert.Equal(t, "data", string(data))
+
+	require.Nil(t, store.Delete(ctx, "events/1.ndjson.gz"))
+	require.Nil(t, store.Delete(ctx, "events/1.ndjson.gz"))
+	_, err = store.Get(ctx, "events/1.ndjson.gz")
+	assert.NotNil(t, err)
+}
+
+func Test_LocalStore_InvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.Nil(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "events/../../secret", ".."} {
+		t.Run(key, func(t *testing.T) {
+			err := store.Put(context.Background(), key, strings.NewReader("data"))
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+}
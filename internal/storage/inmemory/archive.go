@@ -0,0 +1,130 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+type ArchiveStorage struct {
+	mu       sync.RWMutex
+	archives map[string]*models.EventArchive
+	orders   *OrderStorage
+	events   *EventStorage
+}
+
+// NewArchiveStorage uses orders and events to find candidates and remove archived events,
+// storages are passed as types since archive works with their data directly
+func NewArchiveStorage(orders *OrderStorage, events *EventStorage) api.ArchiveStorage {
+	return &ArchiveStorage{
+		archives: make(map[string]*models.EventArchive),
+		orders:   orders,
+		events:   events,
+	}
+}
+
+func (a *ArchiveStorage) GetArchive(ctx context.Context, orderID string) (*models.EventArchive, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	archive := &models.EventArchive{}
+	if row, ok := a.archives[orderID]; ok {
+		*archive = *row
+	}
+	return archive, nil
+}
+
+func (a *ArchiveStorage) GetArchives(ctx context.Context, orderIDs []string) ([]*models.EventArchive, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var archives []*models.EventArchive
+	for _, orderID := range orderIDs {
+		if row, ok := a.archives[orderID]; ok {
+			archive := *row
+			archives = append(archives, &archive)
+		}
+	}
+	return archives, nil
+}
+
+func (a *ArchiveStorage) GetArchiveCandidates(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	a.orders.mu.RLock()
+	defer a.orders.mu.RUnlock()
+
+	var orders []*models.Order
+	for _, order := range a.orders.orders {
+		if !order.IsFinal || !order.FinalizedAt.Before(before) {
+			continue
+		}
+		if _, ok := a.archives[order.ID]; ok {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		if orders[i].FinalizedAt.Equal(orders[j].FinalizedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].FinalizedAt.Before(orders[j].FinalizedAt)
+	})
+	if limit < len(orders) {
+		orders = orders[:limit]
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids, nil
+}
+
+func (a *ArchiveStorage) SaveArchive(ctx context.Context, archive *models.EventArchive, eventIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.archives[archive.OrderID]; ok {
+		return models.ErrAlreadyExist
+	}
+
+	a.events.mu.Lock()
+	defer a.events.mu.Unlock()
+
+	var archived []*models.Event
+	events := a.events.events[:0]
+	for _, event := range a.events.events {
+		if event.OrderID == archive.OrderID && contains(eventIDs, event.EventID) {
+			archived = append(archived, event)
+			continue
+		}
+		events = append(events, event)
+	}
+	a.events.events = events
+	a.events.archived = append(a.events.archived, statusDurations(archived)...)
+
+	row := *archive
+	a.archives[archive.OrderID] = &row
+	return nil
+}
@@ -0,0 +1,106 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+type EventStorage struct {
+	mu     sync.RWMutex
+	events []*models.Event
+	// archived keeps time in status of archived events for stats
+	archived []statusDuration
+}
+
+// statusDuration is time till next event of order, it's nil for the last event
+type statusDuration struct {
+	orderID  string
+	status   string
+	duration *time.Duration
+}
+
+func NewEventStorage() api.EventStorage {
+	return &EventStorage{}
+}
+
+func (e *EventStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.index(event.EventID) >= 0 {
+		return models.ErrAlreadyExist
+	}
+	row := *event
+	e.events = append(e.events, &row)
+	return nil
+}
+
+func (e *EventStorage) UpdateEvent(ctx context.Context, event *models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if i := e.index(event.EventID); i >= 0 {
+		row := *event
+		e.events[i] = &row
+	}
+	return nil
+}
+
+func (e *EventStorage) DeleteEvent(ctx context.Context, eventID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if i := e.index(eventID); i >= 0 {
+		e.events = append(e.events[:i], e.events[i+1:]...)
+	}
+	return nil
+}
+
+func (e *EventStorage) GetEvents(ctx context.Context, filter *models.EventsFilter) ([]*models.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var events []*models.Event
+	for _, event := range e.events {
+		if filter.OrderID != nil && event.OrderID != *filter.OrderID {
+			continue
+		}
+		if filter.EventID != nil && event.EventID != *filter.EventID {
+			continue
+		}
+		if filter.OrderIDs != nil && !contains(filter.OrderIDs, event.OrderID) {
+			continue
+		}
+		row := *event
+		events = append(events, &row)
+	}
+	return events, nil
+}
+
+func (e *EventStorage) index(id string) int {
+	for i, event := range e.events {
+		if event.EventID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,61 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EventStorage(t *testing.T) {
+	var (
+		orderID = "1"
+		eventID = "2"
+	)
+	created := &models.Event{
+		EventID:     "1",
+		OrderID:     orderID,
+		UserID:      "user1",
+		OrderStatus: models.OrderCreatedStatus,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+	}
+	done := &models.Event{
+		EventID:     eventID,
+		OrderID:     orderID,
+		UserID:      "user1",
+		OrderStatus: models.DoneStatus,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 40, 0, time.UTC),
+	}
+	otherOrder := &models.Event{
+		EventID:     "3",
+		OrderID:     "2",
+		UserID:      "user2",
+		OrderStatus: models.OrderCreatedStatus,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+	}
+
+	storage := NewEventStorage()
+	for _, e := range []*models.Event{created, done, otherOrder} {
+		assert.Nil(t, storage.SaveEvent(context.Background(), e))
+	}
+
+	// duplicate
+	assert.Equal(t, models.ErrAlreadyExist, storage.SaveEvent(context.Background(), done))
+
+	events, err := storage.GetEvents(context.Background(), &models.EventsFilter{OrderID: &orderID})
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.Event{created, done}, events)
+
+	finalDone := *done
+	finalDone.IsFinal = true
+	assert.Nil(t, storage.UpdateEvent(context.Background(), &finalDone))
+
+	events, err = storage.GetEvents(context.Background(), &models.EventsFilter{EventID: &eventID})
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.Event{&finalDone}, events)
+}
@@ -0,0 +1,213 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+type OrderStorage struct {
+	mu     sync.RWMutex
+	orders []*models.Order
+}
+
+func NewOrderStorage() api.OrderStorage {
+	return &OrderStorage{}
+}
+
+func (o *OrderStorage) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	// same as postgres storage, return empty order if order is absent
+	order := &models.Order{}
+	if i := o.index(id); i >= 0 {
+		*order = *o.orders[i]
+	}
+	return order, nil
+}
+
+func (o *OrderStorage) SaveOrder(ctx context.Context, order *models.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.index(order.ID) >= 0 {
+		return models.ErrAlreadyExist
+	}
+	row := *order
+	row.Version = 1
+	o.orders = append(o.orders, &row)
+	return nil
+}
+
+func (o *OrderStorage) UpdateOrder(ctx context.Context, order *models.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i := o.index(order.ID)
+	if i < 0 || o.orders[i].Version != order.Version {
+		return models.ErrVersionConflict
+	}
+	row := *order
+	row.Version++
+	o.orders[i] = &row
+	return nil
+}
+
+func (o *OrderStorage) GetOrders(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var orders []*models.Order
+	for _, order := range o.orders {
+		if !matchOrder(order, filter) {
+			continue
+		}
+		row := *order
+		orders = append(orders, &row)
+	}
+
+	if filter.SortBy != nil || filter.SortOrder != nil || filter.After != nil {
+		sortOrders(orders, filter.SortBy, filter.SortOrder)
+	}
+
+	if filter.Offset != nil {
+		if *filter.Offset >= len(orders) {
+			return nil, nil
+		}
+		orders = orders[*filter.Offset:]
+	}
+	if filter.Limit != nil && *filter.Limit < len(orders) {
+		orders = orders[:*filter.Limit]
+	}
+	return orders, nil
+}
+
+// IterateOrders calls fn outside of lock, so fn can use storage
+func (o *OrderStorage) IterateOrders(ctx context.Context, filter *models.OrderFilter, fn func(*models.Order) error) error {
+	orders, err := o.GetOrders(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OrderStorage) index(id string) int {
+	for i, order := range o.orders {
+		if order.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func matchOrder(order *models.Order, filter *models.OrderFilter) bool {
+	if filter.Status != nil && !contains(filter.Status, order.Status) {
+		return false
+	}
+	if filter.UserIDs != nil && !contains(filter.UserIDs, order.UserID) {
+		return false
+	}
+	if filter.OrderIDs != nil && !contains(filter.OrderIDs, order.ID) {
+		return false
+	}
+	if filter.IsFinal != nil && order.IsFinal != *filter.IsFinal {
+		return false
+	}
+	if !inRange(order.CreateAt, filter.CreatedFrom, filter.CreatedTo) || !inRange(order.UpdateAt, filter.UpdatedFrom, filter.UpdatedTo) {
+		return false
+	}
+	if filter.After != nil && !isAfter(order, filter) {
+		return false
+	}
+	return true
+}
+
+func inRange(t time.Time, from *time.Time, to *time.Time) bool {
+	if from != nil && t.Before(*from) {
+		return false
+	}
+	if to != nil && !t.Before(*to) {
+		return false
+	}
+	return true
+}
+
+// same as (time, OrderID) row comparison in sql
+func isAfter(order *models.Order, filter *models.OrderFilter) bool {
+	at := sortKey(order, filter.SortBy)
+	after := filter.After.At.UnixNano()
+	desc := filter.SortOrder == nil || *filter.SortOrder != models.SortAsc
+	if at == after {
+		if desc {
+			return order.ID < filter.After.OrderID
+		}
+		return order.ID > filter.After.OrderID
+	}
+	if desc {
+		return at < after
+	}
+	return at > after
+}
+
+func sortKey(order *models.Order, sortBy *models.SortBy) int64 {
+	if sortBy != nil && *sortBy == models.UpdateAt {
+		return order.UpdateAt.UnixNano()
+	}
+	return order.CreateAt.UnixNano()
+}
+
+// sort by time, OrderID is a tiebreaker for orders with same time
+func sortOrders(orders []*models.Order, sortBy *models.SortBy, sortOrder *models.SortOrder) {
+	asc := sortOrder != nil && *sortOrder == models.SortAsc
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		ki, kj := sortKey(orders[i], sortBy), sortKey(orders[j], sortBy)
+		if ki == kj {
+			if asc {
+				return orders[i].ID < orders[j].ID
+			}
+			return orders[i].ID > orders[j].ID
+		}
+		if asc {
+			return ki < kj
+		}
+		return ki > kj
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	firstOrder = &models.Order{
+		ID:       "1",
+		UserID:   "user1",
+		Status:   models.OrderCreatedStatus,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 50, 0, time.UTC),
+		Version:  1,
+	}
+	secondOrder = &models.Order{
+		ID:       "2",
+		UserID:   "user2",
+		Status:   models.PendingStatus,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 40, 0, time.UTC),
+		Version:  1,
+	}
+	thirdOrder = &models.Order{
+		ID:       "3",
+		UserID:   "user1",
+		Status:   models.FailedStatus,
+		IsFinal:  true,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 32, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 45, 0, time.UTC),
+		Version:  1,
+	}
+)
+
+func newTestOrderStorage(t *testing.T) *OrderStorage {
+	storage := &OrderStorage{}
+	for _, o := range []*models.Order{firstOrder, secondOrder, thirdOrder} {
+		err := storage.SaveOrder(context.Background(), o)
+		assert.Nil(t, err)
+	}
+	return storage
+}
+
+func Test_OrderStorage_GetOrder(t *testing.T) {
+	storage := newTestOrderStorage(t)
+
+	order, err := storage.GetOrder(context.Background(), secondOrder.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, secondOrder, order)
+
+	order, err = storage.GetOrder(context.Background(), "unknown")
+	assert.Nil(t, err)
+	assert.Equal(t, &models.Order{}, order)
+}
+
+func Test_OrderStorage_SaveOrder(t *testing.T) {
+	storage := newTestOrderStorage(t)
+
+	err := storage.SaveOrder(context.Background(), firstOrder)
+	assert.Equal(t, models.ErrAlreadyExist, err)
+}
+
+func Test_OrderStorage_UpdateOrder(t *testing.T) {
+	storage := newTestOrderStorage(t)
+
+	updated := *firstOrder
+	updated.Status = models.PendingStatus
+	err := storage.UpdateOrder(context.Background(), &updated)
+	assert.Nil(t, err)
+
+	order, err := storage.GetOrder(context.Background(), firstOrder.ID)
+	assert.Nil(t, err)
+	updated.Version = 2
+	assert.Equal(t, &updated, order)
+
+	// update of stale version fails
+	err = storage.UpdateOrder(context.Background(), firstOrder)
+	assert.Equal(t, models.ErrVersionConflict, err)
+
+	// stored order isn't shared with caller
+	updated.Status = models.FailedStatus
+	order, _ = storage.GetOrder(context.Background(), firstOrder.ID)
+	assert.Equal(t, models.PendingStatus, order.Status)
+}
+
+func Test_OrderStorage_GetOrders(t *testing.T) {
+	var (
+		userID     = "user1"
+		isFinal    = false
+		limit      = 1
+		offset     = 1
+		bigOffset  = 10
+		byCreate   = models.CreateAt
+		byUpdate   = models.UpdateAt
+		orderAsc   = models.SortAsc
+		orderDesc  = models.SortDesc
+		notCreated = []string{models.PendingStatus, models.FailedStatus}
+	)
+
+	testCases := []struct {
+		name   string
+		filter *models.OrderFilter
+		exp    []*models.Order
+	}{
+		{
+			name:   "without filters",
+			filter: &models.OrderFilter{},
+			exp:    []*models.Order{firstOrder, secondOrder, thirdOrder},
+		},
+		{
+			name:   "by statuses",
+			filter: &models.OrderFilter{Status: notCreated},
+			exp:    []*models.Order{secondOrder, thirdOrder},
+		},
+		{
+			name:   "by user and isFinal",
+			filter: &models.OrderFilter{UserIDs: []string{userID}, IsFinal: &isFinal},
+			exp:    []*models.Order{firstOrder},
+		},
+		{
+			name:   "sort by created_at desc",
+			filter: &models.OrderFilter{SortBy: &byCreate, SortOrder: &orderDesc},
+			exp:    []*models.Order{thirdOrder, secondOrder, firstOrder},
+		},
+		{
+			name:   "sort by update_at asc",
+			filter: &models.OrderFilter{SortBy: &byUpdate, SortOrder: &orderAsc},
+			exp:    []*models.Order{secondOrder, thirdOrder, firstOrder},
+		},
+		{
+			name:   "limit and offset",
+			filter: &models.OrderFilter{SortBy: &byCreate, SortOrder: &orderAsc, Limit: &limit, Offset: &offset},
+			exp:    []*models.Order{secondOrder},
+		},
+		{
+			name: "after cursor",
+			filter: &models.OrderFilter{
+				SortBy:    &byCreate,
+				SortOrder: &orderDesc,
+				After:     &models.OrderCursor{At: thirdOrder.CreateAt, OrderID: thirdOrder.ID},
+			},
+			exp: []*models.Order{secondOrder, firstOrder},
+		},
+		{
+			name:   "offset out of range",
+			filter: &models.OrderFilter{Offset: &bigOffset},
+			exp:    nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := newTestOrderStorage(t)
+
+			orders, err := storage.GetOrders(context.Background(), tc.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.exp, orders)
+		})
+	}
+}
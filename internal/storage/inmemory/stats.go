@@ -0,0 +1,185 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+)
+
+type StatsStorage struct {
+	orders *OrderStorage
+	events *EventStorage
+}
+
+func NewStatsStorage(orders *OrderStorage, events *EventStorage) api.StatsStorage {
+	return &StatsStorage{
+		orders: orders,
+		events: events,
+	}
+}
+
+type countKey struct {
+	bucket  time.Time
+	status  string
+	isFinal bool
+}
+
+func (s *StatsStorage) CountOrders(ctx context.Context, filter *models.StatsFilter) ([]*models.OrdersCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.orders.mu.RLock()
+	defer s.orders.mu.RUnlock()
+
+	counts := make(map[countKey]int)
+	for _, order := range s.orders.orders {
+		if !matchStats(order, filter) {
+			continue
+		}
+		key := countKey{status: order.Status, isFinal: order.IsFinal}
+		switch filter.Bucket {
+		case models.BucketHour:
+			key.bucket = order.CreateAt.UTC().Truncate(time.Hour)
+		case models.BucketDay:
+			t := order.CreateAt.UTC()
+			key.bucket = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		counts[key]++
+	}
+
+	result := make([]*models.OrdersCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, &models.OrdersCount{
+			Bucket:  key.bucket,
+			Status:  key.status,
+			IsFinal: key.isFinal,
+			Count:   count,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Bucket.Before(result[j].Bucket)
+	})
+	return result, nil
+}
+
+func (s *StatsStorage) CountReached(ctx context.Context, filter *models.StatsFilter) (map[string]int, error) {
+	durations, err := s.statusDurations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	reached := make(map[string]int)
+	for _, orderDurations := range durations {
+		statuses := make(map[string]bool)
+		for _, d := range orderDurations {
+			statuses[d.status] = true
+		}
+		for status := range statuses {
+			reached[status]++
+		}
+	}
+	return reached, nil
+}
+
+func (s *StatsStorage) GetStatusPercentiles(ctx context.Context, filter *models.StatsFilter) (map[string]models.Percentiles, error) {
+	durations, err := s.statusDurations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byStatus := make(map[string][]time.Duration)
+	for _, orderDurations := range durations {
+		for _, d := range orderDurations {
+			if d.duration != nil {
+				byStatus[d.status] = append(byStatus[d.status], *d.duration)
+			}
+		}
+	}
+
+	// percentiles use nearest rank method
+	result := make(map[string]models.Percentiles, len(byStatus))
+	for status, values := range byStatus {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		rank := func(p int) time.Duration {
+			i := (p*len(values)+99)/100 - 1
+			return values[max(i, 0)]
+		}
+		result[status] = models.Percentiles{
+			Count: len(values),
+			P50:   rank(50),
+			P90:   rank(90),
+			P99:   rank(99),
+		}
+	}
+	return result, nil
+}
+
+// statusDurations groups time in status of live and archived events by orders matched by filter
+func (s *StatsStorage) statusDurations(ctx context.Context, filter *models.StatsFilter) (map[string][]statusDuration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.orders.mu.RLock()
+	defer s.orders.mu.RUnlock()
+	s.events.mu.RLock()
+	defer s.events.mu.RUnlock()
+
+	matched := make(map[string]bool)
+	for _, order := range s.orders.orders {
+		if matchStats(order, filter) {
+			matched[order.ID] = true
+		}
+	}
+
+	events := make(map[string][]*models.Event)
+	for _, e := range s.events.events {
+		if matched[e.OrderID] {
+			events[e.OrderID] = append(events[e.OrderID], e)
+		}
+	}
+
+	durations := make(map[string][]statusDuration)
+	for orderID, orderEvents := range events {
+		durations[orderID] = statusDurations(orderEvents)
+	}
+	for _, d := range s.events.archived {
+		if matched[d.orderID] {
+			durations[d.orderID] = append(durations[d.orderID], d)
+		}
+	}
+	return durations, nil
+}
+
+// statusDurations returns time till next event for events of one order
+func statusDurations(events []*models.Event) []statusDuration {
+	sorted := make([]*models.Event, len(events))
+	copy(sorted, events)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].UpdateAt.Equal(sorted[j].UpdateAt) {
+			return sorted[i].EventID < sorted[j].EventID
+		}
+		return sorted[i].UpdateAt.Before(sorted[j].UpdateAt)
+	})
+
+	durations := make([]statusDuration, 0, len(sorted))
+	for i, e := range sorted {
+		d := statusDuration{orderID: e.OrderID, status: e.OrderStatus}
+		if i+1 < len(sorted) {
+			next := sorted[i+1].UpdateAt.Sub(e.UpdateAt)
+			d.duration = &next
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
+
+func matchStats(order *models.Order, filter *models.StatsFilter) bool {
+	if filter.UserIDs != nil && !contains(filter.UserIDs, order.UserID) {
+		return false
+	}
+	return inRange(order.CreateAt, filter.From, filter.To)
+}
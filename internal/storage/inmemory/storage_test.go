@@ -0,0 +1,18 @@
+package inmemory
+
+import (
+	"testing"
+	"webhooker/internal/storage/storagetest"
+)
+
+func Test_StorageSuite(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		orders, events := &OrderStorage{}, &EventStorage{}
+		return storagetest.Storage{
+			Orders:   orders,
+			Events:   events,
+			Archives: NewArchiveStorage(orders, events),
+			Stats:    NewStatsStorage(orders, events),
+		}
+	})
+}
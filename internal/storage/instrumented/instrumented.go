@@ -0,0 +1,165 @@
+// Package instrumented wraps storages to measure duration of their operations and trace them
+package instrumented
+
+import (
+	"context"
+	"time"
+	"webhooker/internal/metrics"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("webhooker/internal/storage")
+
+type observer struct {
+	driver string
+}
+
+// start starts span of operation, returned func ends span and observes duration
+func (o observer) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "db."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", o.driver), attribute.String("db.operation", operation)))
+	return ctx, func(err error) {
+		metrics.DBQueryDuration.WithLabelValues(o.driver, operation).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}
+}
+
+type OrderStorage struct {
+	observer
+	next api.OrderStorage
+}
+
+func NewOrderStorage(next api.OrderStorage, driver string) api.OrderStorage {
+	return &OrderStorage{observer: observer{driver: driver}, next: next}
+}
+
+func (s *OrderStorage) GetOrder(ctx context.Context, id string) (res *models.Order, err error) {
+	ctx, end := s.start(ctx, "get_order")
+	defer func() { end(err) }()
+	return s.next.GetOrder(ctx, id)
+}
+
+func (s *OrderStorage) GetOrders(ctx context.Context, filter *models.OrderFilter) (res []*models.Order, err error) {
+	ctx, end := s.start(ctx, "get_orders")
+	defer func() { end(err) }()
+	return s.next.GetOrders(ctx, filter)
+}
+
+// IterateOrders measures whole iteration including callbacks
+func (s *OrderStorage) IterateOrders(ctx context.Context, filter *models.OrderFilter, fn func(*models.Order) error) (err error) {
+	ctx, end := s.start(ctx, "iterate_orders")
+	defer func() { end(err) }()
+	return s.next.IterateOrders(ctx, filter, fn)
+}
+
+func (s *OrderStorage) SaveOrder(ctx context.Context, order *models.Order) (err error) {
+	ctx, end := s.start(ctx, "save_order")
+	defer func() { end(err) }()
+	return s.next.SaveOrder(ctx, order)
+}
+
+func (s *OrderStorage) UpdateOrder(ctx context.Context, order *models.Order) (err error) {
+	ctx, end := s.start(ctx, "update_order")
+	defer func() { end(err) }()
+	return s.next.UpdateOrder(ctx, order)
+}
+
+type EventStorage struct {
+	observer
+	next api.EventStorage
+}
+
+func NewEventStorage(next api.EventStorage, driver string) api.EventStorage {
+	return &EventStorage{observer: observer{driver: driver}, next: next}
+}
+
+func (s *EventStorage) SaveEvent(ctx context.Context, event *models.Event) (err error) {
+	ctx, end := s.start(ctx, "save_event")
+	defer func() { end(err) }()
+	return s.next.SaveEvent(ctx, event)
+}
+
+func (s *EventStorage) UpdateEvent(ctx context.Context, event *models.Event) (err error) {
+	ctx, end := s.start(ctx, "update_event")
+	defer func() { end(err) }()
+	return s.next.UpdateEvent(ctx, event)
+}
+
+func (s *EventStorage) DeleteEvent(ctx context.Context, eventID string) (err error) {
+	ctx, end := s.start(ctx, "delete_event")
+	defer func() { end(err) }()
+	return s.next.DeleteEvent(ctx, eventID)
+}
+
+func (s *EventStorage) GetEvents(ctx context.Context, filter *models.EventsFilter) (res []*models.Event, err error) {
+	ctx, end := s.start(ctx, "get_events")
+	defer func() { end(err) }()
+	return s.next.GetEvents(ctx, filter)
+}
+
+type ArchiveStorage struct {
+	observer
+	next api.ArchiveStorage
+}
+
+func NewArchiveStorage(next api.ArchiveStorage, driver string) api.ArchiveStorage {
+	return &ArchiveStorage{observer: observer{driver: driver}, next: next}
+}
+
+func (s *ArchiveStorage) GetArchive(ctx context.Context, orderID string) (res *models.EventArchive, err error) {
+	ctx, end := s.start(ctx, "get_archive")
+	defer func() { end(err) }()
+	return s.next.GetArchive(ctx, orderID)
+}
+
+func (s *ArchiveStorage) GetArchives(ctx context.Context, orderIDs []string) (res []*models.EventArchive, err error) {
+	ctx, end := s.start(ctx, "get_archives")
+	defer func() { end(err) }()
+	return s.next.GetArchives(ctx, orderIDs)
+}
+
+func (s *ArchiveStorage) GetArchiveCandidates(ctx context.Context, before time.Time, limit int) (res []string, err error) {
+	ctx, end := s.start(ctx, "get_archive_candidates")
+	defer func() { end(err) }()
+	return s.next.GetArchiveCandidates(ctx, before, limit)
+}
+
+func (s *ArchiveStorage) SaveArchive(ctx context.Context, archive *models.EventArchive, eventIDs []string) (err error) {
+	ctx, end := s.start(ctx, "save_archive")
+	defer func() { end(err) }()
+	return s.next.SaveArchive(ctx, archive, eventIDs)
+}
+
+type StatsStorage struct {
+	observer
+	next api.StatsStorage
+}
+
+func NewStatsStorage(next api.StatsStorage, driver string) api.StatsStorage {
+	return &StatsStorage{observer: observer{driver: driver}, next: next}
+}
+
+func (s *StatsStorage) CountOrders(ctx context.Context, filter *models.StatsFilter) (res []*models.OrdersCount, err error) {
+	ctx, end := s.start(ctx, "count_orders")
+	defer func() { end(err) }()
+	return s.next.CountOrders(ctx, filter)
+}
+
+func (s *StatsStorage) CountReached(ctx context.Context, filter *models.StatsFilter) (res map[string]int, err error) {
+	ctx, end := s.start(ctx, "count_reached")
+	defer func() { end(err) }()
+	return s.next.CountReached(ctx, filter)
+}
+
+func (s *StatsStorage) GetStatusPercentiles(ctx context.Context, filter *models.StatsFilter) (res map[string]models.Percentiles, err error) {
+	ctx, end := s.start(ctx, "get_status_percentiles")
+	defer func() { end(err) }()
+	return s.next.GetStatusPercentiles(ctx, filter)
+}
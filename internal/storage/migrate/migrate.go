@@ -0,0 +1,281 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	seedFile = "seed.sql"
+
+	createVersionTable = `CREATE TABLE IF NOT EXISTS SchemaMigrations (
+	Version INTEGER PRIMARY KEY,
+	Name VARCHAR(255) NOT NULL,
+	AppliedAt TIMESTAMP NOT NULL
+)`
+)
+
+var (
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+	ErrSchemaNewer    = errors.New("database schema is newer than application")
+	ErrNoSeed         = errors.New("seed script is absent")
+
+	// 0001_create_orders.up.sql
+	fileNameRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies versioned sql scripts and keeps applied versions in SchemaMigrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	seed       string
+}
+
+// New reads migrations from fsys root, fsys can contain seed.sql with test data
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations, err: %w", err)
+	}
+
+	m := &Migrator{db: db}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s, err: %w", entry.Name(), err)
+		}
+		if entry.Name() == seedFile {
+			m.seed = string(content)
+			continue
+		}
+
+		match := fileNameRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version %s", entry.Name())
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d requires up and down scripts", migration.Version)
+		}
+		m.migrations = append(m.migrations, *migration)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return m, nil
+}
+
+// Latest returns version expected by application
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns last applied version, 0 for empty database
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Check returns error if database schema doesn't match application
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaOutdated, version, m.Latest())
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaNewer, version, m.Latest())
+	}
+	return nil
+}
+
+// Up applies all pending migrations and returns amount of applied
+func (m *Migrator) Up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO SchemaMigrations (Version, Name, AppliedAt) VALUES ($1, $2, $3)",
+				migration.Version, migration.Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to apply migration %d_%s, err: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down reverts last applied migrations and returns amount of reverted
+func (m *Migrator) Down(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM SchemaMigrations WHERE Version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to revert migration %d_%s, err: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Status returns all known migrations, AppliedAt is nil for pending
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Seed inserts test data, schema should be up to date
+func (m *Migrator) Seed() error {
+	if m.seed == "" {
+		return ErrNoSeed
+	}
+	if err := m.Check(); err != nil {
+		return err
+	}
+	return m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.seed); err != nil {
+			return fmt.Errorf("failed to seed, err: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	_, err := m.db.Exec(createVersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrations table, err: %w", err)
+	}
+
+	rows, err := m.db.Query("SELECT Version, AppliedAt FROM SchemaMigrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migrations, err: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration row %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run get migrations query: %w", err)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction, err: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_init.up.sql":         {Data: []byte("CREATE TABLE Orders (OrderID VARCHAR(37))")},
+	"0001_init.down.sql":       {Data: []byte("DROP TABLE Orders")},
+	"0002_add_column.up.sql":   {Data: []byte("ALTER TABLE Orders ADD COLUMN IsFinal BOOLEAN")},
+	"0002_add_column.down.sql": {Data: []byte("ALTER TABLE Orders DROP COLUMN IsFinal")},
+	"seed.sql":                 {Data: []byte("INSERT INTO Orders VALUES ('1')")},
+}
+
+func Test_New(t *testing.T) {
+	testCases := []struct {
+		name      string
+		fsys      fstest.MapFS
+		expLatest int
+		expErr    bool
+	}{
+		{
+			name:      "valid",
+			fsys:      testMigrations,
+			expLatest: 2,
+		},
+		{
+			name: "without down",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("CREATE TABLE Orders (OrderID VARCHAR(37))")},
+			},
+			expErr: true,
+		},
+		{
+			name: "unexpected file",
+			fsys: fstest.MapFS{
+				"init.sql": {Data: []byte("CREATE TABLE Orders (OrderID VARCHAR(37))")},
+			},
+			expErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(nil, tc.fsys)
+			if tc.expErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expLatest, m.Latest())
+		})
+	}
+}
+
+func Test_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS SchemaMigrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT Version, AppliedAt FROM SchemaMigrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"Version", "AppliedAt"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE Orders ADD COLUMN IsFinal BOOLEAN`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO SchemaMigrations \(Version, Name, AppliedAt\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(2, "add_column", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m, err := New(db, testMigrations)
+	assert.Nil(t, err)
+
+	count, err := m.Up()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Check(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS SchemaMigrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT Version, AppliedAt FROM SchemaMigrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"Version", "AppliedAt"}).AddRow(1, time.Now()))
+
+	m, err := New(db, testMigrations)
+	assert.Nil(t, err)
+
+	err = m.Check()
+	assert.True(t, errors.Is(err, ErrSchemaOutdated))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,9 +1,13 @@
 package posgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"time"
 	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
 
 	"github.com/lib/pq"
 )
@@ -14,8 +18,10 @@ type EventRow struct {
 	UserID      string
 	OrderStatus string
 	IsFinal     bool
+	IsSystem    bool
 	CreateAt    time.Time
 	UpdateAt    time.Time
+	ReceivedAt  sql.NullTime
 }
 
 func (e *EventRow) EventRowToEvent() *models.Event {
@@ -25,8 +31,10 @@ func (e *EventRow) EventRowToEvent() *models.Event {
 		UserID:      e.UserID,
 		OrderStatus: e.OrderStatus,
 		IsFinal:     e.IsFinal,
+		IsSystem:    e.IsSystem,
 		CreateAt:    e.CreateAt,
 		UpdateAt:    e.UpdateAt,
+		ReceivedAt:  e.ReceivedAt.Time,
 	}
 }
 
@@ -36,27 +44,29 @@ func (e *EventRow) EventRowFromEvent(event *models.Event) {
 	e.UserID = event.UserID
 	e.OrderStatus = event.OrderStatus
 	e.IsFinal = event.IsFinal
+	e.IsSystem = event.IsSystem
 	e.CreateAt = event.CreateAt
 	e.UpdateAt = event.UpdateAt
+	e.ReceivedAt = sql.NullTime{Time: event.ReceivedAt, Valid: !event.ReceivedAt.IsZero()}
 }
 
 type EventStorage struct {
 	db *PgClient
 }
 
-func NewEventStorage(client *PgClient) *EventStorage {
+func NewEventStorage(client *PgClient) api.EventStorage {
 	return &EventStorage{
 		db: client,
 	}
 }
 
-func (e *EventStorage) SaveEvent(event *models.Event) error {
+func (e *EventStorage) SaveEvent(ctx context.Context, event *models.Event) error {
 	var eventRow EventRow
 	eventRow.EventRowFromEvent(event)
 
-	query := "INSERT INTO Events(EventID, OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt) VALUES($1, $2, $3, $4, $5, $6, $7)"
+	query := "INSERT INTO Events(EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 
-	_, err := e.db.client.Exec(query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.CreateAt, eventRow.UpdateAt)
+	_, err := e.db.client.ExecContext(ctx, query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.IsSystem, eventRow.CreateAt, eventRow.UpdateAt, eventRow.ReceivedAt)
 	if err != nil {
 		if err, ok := err.(*pq.Error); ok {
 			if err.Code.Name() == "unique_violation" {
@@ -68,45 +78,58 @@ func (e *EventStorage) SaveEvent(event *models.Event) error {
 	return nil
 }
 
-func (e *EventStorage) UpdateEvent(event *models.Event) error {
+func (e *EventStorage) UpdateEvent(ctx context.Context, event *models.Event) error {
 	query := `UPDATE Events
-	SET EventID = $1, OrderID = $2, UserID = $3, OrderStatus = $4, IsFinal = $5, CreateAt = $6, UpdateAt = $7
+	SET EventID = $1, OrderID = $2, UserID = $3, OrderStatus = $4, IsFinal = $5, IsSystem = $6, CreateAt = $7, UpdateAt = $8, ReceivedAt = $9
 	WHERE EventID = $1`
 
 	var eventRow EventRow
 	eventRow.EventRowFromEvent(event)
 
-	_, err := e.db.client.Exec(query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.CreateAt, eventRow.UpdateAt)
+	_, err := e.db.client.ExecContext(ctx, query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.IsSystem, eventRow.CreateAt, eventRow.UpdateAt, eventRow.ReceivedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update event, err: %w", err)
 	}
 	return nil
 }
 
-func (e *EventStorage) GetEvents(filter *models.EventsFilter) ([]*models.Event, error) {
-	query := `SELECT EventID, OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt 
-	FROM Events`
+func (e *EventStorage) DeleteEvent(ctx context.Context, eventID string) error {
+	_, err := e.db.client.ExecContext(ctx, "DELETE FROM Events WHERE EventID = $1", eventID)
+	if err != nil {
+		return fmt.Errorf("failed to delete event, err: %w", err)
+	}
+	return nil
+}
+
+func (e *EventStorage) GetEvents(ctx context.Context, filter *models.EventsFilter) ([]*models.Event, error) {
+	q := query.New(`SELECT EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt 
+	FROM Events`)
 
 	if filter.OrderID != nil {
-		whereStmt := fmt.Sprintf("OrderID = '%s'", *filter.OrderID)
-		query = addWhere(query, whereStmt)
+		q.Where("OrderID = ?", *filter.OrderID)
 	}
 
 	if filter.EventID != nil {
-		whereStmt := fmt.Sprintf("EventID = '%s'", *filter.EventID)
-		query = addWhere(query, whereStmt)
+		q.Where("EventID = ?", *filter.EventID)
+	}
+
+	if filter.OrderIDs != nil {
+		q.WhereIn("OrderID", filter.OrderIDs)
 	}
 
-	rows, err := e.db.client.Query(query)
+	stmt, args := q.Build()
+
+	rows, err := e.db.client.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events %w", err)
 	}
+	defer rows.Close()
 
 	var events []*models.Event
 
 	for rows.Next() {
 		var eventRow EventRow
-		err := rows.Scan(&eventRow.EventID, &eventRow.OrderID, &eventRow.UserID, &eventRow.OrderStatus, &eventRow.IsFinal, &eventRow.CreateAt, &eventRow.UpdateAt)
+		err := rows.Scan(&eventRow.EventID, &eventRow.OrderID, &eventRow.UserID, &eventRow.OrderStatus, &eventRow.IsFinal, &eventRow.IsSystem, &eventRow.CreateAt, &eventRow.UpdateAt, &eventRow.ReceivedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event row %w", err)
 		}
@@ -0,0 +1,69 @@
+package posgres
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	eventsColumn = []string{"EventID", "OrderID", "UserID", "OrderStatus", "IsFinal", "IsSystem", "CreateAt", "UpdateAt", "ReceivedAt"}
+)
+
+func Test_GetEvents_HostileInput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		orderID = "1'; DELETE FROM Events; --"
+		eventID = "' OR ''='"
+	)
+
+	mock.ExpectQuery(`SELECT EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt FROM Events WHERE OrderID = \$1 AND EventID = \$2`).
+		WithArgs(orderID, eventID).
+		WillReturnRows(sqlmock.NewRows(eventsColumn))
+
+	storage := EventStorage{db: &PgClient{db}}
+
+	events, err := storage.GetEvents(context.Background(), &models.EventsFilter{OrderID: &orderID, EventID: &eventID})
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_SaveEvent_IsSystem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	event := &models.Event{
+		EventID:     "eventID",
+		OrderID:     "orderID",
+		UserID:      "userID",
+		OrderStatus: models.FailedStatus,
+		IsFinal:     true,
+		IsSystem:    true,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 40, 30, 0, time.UTC),
+		ReceivedAt:  time.Date(2022, 10, 10, 11, 40, 31, 0, time.UTC),
+	}
+
+	mock.ExpectExec(`INSERT INTO Events\(EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt\) VALUES\(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\)`).
+		WithArgs(event.EventID, event.OrderID, event.UserID, event.OrderStatus, event.IsFinal, event.IsSystem, event.CreateAt, event.UpdateAt, event.ReceivedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	storage := EventStorage{db: &PgClient{db}}
+
+	err = storage.SaveEvent(context.Background(), event)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
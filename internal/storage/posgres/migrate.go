@@ -0,0 +1,18 @@
+package posgres
+
+import (
+	"embed"
+	"io/fs"
+	"webhooker/internal/storage/migrate"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+func NewMigrator(client *PgClient) (*migrate.Migrator, error) {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(client.client, fsys)
+}
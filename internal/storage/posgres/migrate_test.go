@@ -0,0 +1,13 @@
+package posgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewMigrator(t *testing.T) {
+	m, err := NewMigrator(&PgClient{})
+	assert.Nil(t, err)
+	assert.Equal(t, 7, m.Latest())
+}
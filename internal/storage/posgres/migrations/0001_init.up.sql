@@ -0,0 +1,21 @@
+-- tables can already exist in databases created by the init script before migrations
+CREATE TABLE IF NOT EXISTS Orders (
+    OrderID VARCHAR(37) PRIMARY KEY,
+    UserId VARCHAR(37) NOT NULL,
+    OrderStatus VARCHAR(50) NOT NULL,
+    IsFinal BOOLEAN NOT NULL,
+    CreateAt TIMESTAMP NOT NULL,
+    UpdateAt TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS Events (
+    EventID VARCHAR(37) PRIMARY KEY NOT NULL,
+    OrderID VARCHAR(37) NOT NULL,
+    UserID VARCHAR(37) NOT NULL,
+    OrderStatus VARCHAR(50) NOT NULL,
+    IsFinal BOOLEAN NOT NULL,
+    CreateAt TIMESTAMP NOT NULL,
+    UpdateAt TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS events_orderid ON Events (OrderID);
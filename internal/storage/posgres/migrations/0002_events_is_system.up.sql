@@ -0,0 +1 @@
+ALTER TABLE Events ADD COLUMN IF NOT EXISTS IsSystem BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,6 @@
+CREATE TABLE EventArchives (
+    OrderID VARCHAR(37) PRIMARY KEY,
+    ArchiveKey VARCHAR(255) NOT NULL,
+    EventsCount INTEGER NOT NULL,
+    ArchivedAt TIMESTAMP NOT NULL
+);
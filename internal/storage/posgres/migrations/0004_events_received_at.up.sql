@@ -0,0 +1 @@
+ALTER TABLE Events ADD COLUMN IF NOT EXISTS ReceivedAt TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE Orders ADD COLUMN IF NOT EXISTS Version INTEGER NOT NULL DEFAULT 1;
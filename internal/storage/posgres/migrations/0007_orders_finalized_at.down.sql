@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS orders_finalizedat;
+ALTER TABLE Orders DROP COLUMN IF EXISTS FinalizedAt;
@@ -0,0 +1,5 @@
+-- archive waits for retention after order became final, UpdateAt is time of provider
+ALTER TABLE Orders ADD COLUMN IF NOT EXISTS FinalizedAt TIMESTAMP;
+-- time of finalization of existing orders is unknown, retention starts from migration
+UPDATE Orders SET FinalizedAt = (now() AT TIME ZONE 'UTC') WHERE IsFinal AND FinalizedAt IS NULL;
+CREATE INDEX IF NOT EXISTS orders_finalizedat ON Orders (FinalizedAt);
@@ -1,11 +1,15 @@
 package posgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 	"webhooker/internal/services/models"
 	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
+
+	"github.com/lib/pq"
 )
 
 type OrderRow struct {
@@ -15,16 +19,20 @@ type OrderRow struct {
 	IsFinal     bool
 	CreateAt    time.Time
 	UpdateAt    time.Time
+	FinalizedAt sql.NullTime
+	Version     int
 }
 
 func (o *OrderRow) OrderRowToOrder() *models.Order {
 	return &models.Order{
-		ID:       o.OrderID,
-		UserID:   o.UserID,
-		Status:   o.OrderStatus,
-		IsFinal:  o.IsFinal,
-		CreateAt: o.CreateAt,
-		UpdateAt: o.UpdateAt,
+		ID:          o.OrderID,
+		UserID:      o.UserID,
+		Status:      o.OrderStatus,
+		IsFinal:     o.IsFinal,
+		CreateAt:    o.CreateAt,
+		UpdateAt:    o.UpdateAt,
+		FinalizedAt: o.FinalizedAt.Time,
+		Version:     o.Version,
 	}
 }
 
@@ -35,6 +43,8 @@ func (o *OrderRow) OrderRowFromOrder(order *models.Order) {
 	o.IsFinal = order.IsFinal
 	o.CreateAt = order.CreateAt
 	o.UpdateAt = order.UpdateAt
+	o.FinalizedAt = sql.NullTime{Time: order.FinalizedAt, Valid: !order.FinalizedAt.IsZero()}
+	o.Version = order.Version
 }
 
 type OrderStorage struct {
@@ -47,12 +57,12 @@ func NewOrderStorage(client *PgClient) api.OrderStorage {
 	}
 }
 
-func (o *OrderStorage) GetOrder(id string) (*models.Order, error) {
-	query := `SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt 
+func (o *OrderStorage) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	query := `SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version 
 	FROM Orders
 	WHERE OrderID = $1`
 
-	rows, err := o.db.client.Query(query, id)
+	rows, err := o.db.client.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query order, err: %w", err)
 	}
@@ -60,7 +70,7 @@ func (o *OrderStorage) GetOrder(id string) (*models.Order, error) {
 
 	var orderRow OrderRow
 	for rows.Next() {
-		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt)
+		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt, &orderRow.FinalizedAt, &orderRow.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order row %w", err)
 		}
@@ -71,121 +81,145 @@ func (o *OrderStorage) GetOrder(id string) (*models.Order, error) {
 	return orderRow.OrderRowToOrder(), nil
 }
 
-func (o *OrderStorage) SaveOrder(order *models.Order) error {
-	query := `INSERT INTO Orders (OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt)
-	VALUES ( $1, $2, $3, $4, $5, $6)`
+func (o *OrderStorage) SaveOrder(ctx context.Context, order *models.Order) error {
+	query := `INSERT INTO Orders (OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version)
+	VALUES ( $1, $2, $3, $4, $5, $6, $7, 1)`
 
 	var orderRow OrderRow
 	orderRow.OrderRowFromOrder(order)
 
-	_, err := o.db.client.Exec(query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt)
+	_, err := o.db.client.ExecContext(ctx, query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt, orderRow.FinalizedAt)
 	if err != nil {
+		if err, ok := err.(*pq.Error); ok {
+			if err.Code.Name() == "unique_violation" {
+				return models.ErrAlreadyExist
+			}
+		}
 		return fmt.Errorf("failed to insert order, err: %w", err)
 	}
 
 	return nil
 }
 
-func (o *OrderStorage) UpdateOrder(order *models.Order) error {
+func (o *OrderStorage) UpdateOrder(ctx context.Context, order *models.Order) error {
 	query := `UPDATE Orders
-	SET OrderID = $1, UserId = $2, OrderStatus = $3, IsFinal = $4, CreateAt = $5, UpdateAt = $6 
-	WHERE OrderID = $1`
+	SET OrderID = $1, UserId = $2, OrderStatus = $3, IsFinal = $4, CreateAt = $5, UpdateAt = $6, FinalizedAt = $8, Version = Version + 1
+	WHERE OrderID = $1 AND Version = $7`
 
 	var orderRow OrderRow
 	orderRow.OrderRowFromOrder(order)
 
-	_, err := o.db.client.Exec(query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt)
+	res, err := o.db.client.ExecContext(ctx, query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt, orderRow.Version, orderRow.FinalizedAt)
 	if err != nil {
 		return fmt.Errorf("failed to exec update order, err: %w", err)
 	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated rows, err: %w", err)
+	}
+	// order was changed or removed after it was read
+	if updated == 0 {
+		return models.ErrVersionConflict
+	}
 	return nil
 }
 
-func (o *OrderStorage) GetOrders(filter *models.OrderFilter) ([]*models.Order, error) {
-	query := `SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt 
-	FROM Orders`
+func (o *OrderStorage) GetOrders(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := o.IterateOrders(ctx, filter, func(order *models.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// IterateOrders scans rows one by one, so memory doesn't depend on number of orders
+func (o *OrderStorage) IterateOrders(ctx context.Context, filter *models.OrderFilter, fn func(*models.Order) error) error {
+	q := query.New(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version 
+	FROM Orders`)
 
 	if filter.Status != nil {
-		var statuses string
-		for i := 0; i < len(filter.Status); i++ {
-			if i == 0 {
-				statuses = fmt.Sprintf("'%s'", filter.Status[i])
-			} else {
-				statuses = fmt.Sprintf("%s, '%s'", statuses, filter.Status[i])
-			}
-		}
+		q.WhereIn("OrderStatus", filter.Status)
+	}
 
-		whereStmt := fmt.Sprintf("OrderStatus IN (%s)", statuses)
-		query = addWhere(query, whereStmt)
+	if filter.UserIDs != nil {
+		q.WhereIn("UserID", filter.UserIDs)
 	}
 
-	if filter.UserID != nil {
-		whereStmt := fmt.Sprintf("UserID = '%s'", *filter.UserID)
-		query = addWhere(query, whereStmt)
+	if filter.OrderIDs != nil {
+		q.WhereIn("OrderID", filter.OrderIDs)
 	}
 
 	if filter.IsFinal != nil {
-		whereStmt := fmt.Sprintf("IsFinal = '%t'", *filter.IsFinal)
-		query = addWhere(query, whereStmt)
+		q.Where("IsFinal = ?", *filter.IsFinal)
+	}
+
+	if filter.CreatedFrom != nil {
+		q.Where("CreateAt >= ?", *filter.CreatedFrom)
 	}
 
-	if filter.SortBy != nil || filter.SortOrder != nil {
-		var (
-			by    string
-			order string
-		)
-		if *filter.SortBy == models.UpdateAt {
+	if filter.CreatedTo != nil {
+		q.Where("CreateAt < ?", *filter.CreatedTo)
+	}
+
+	if filter.UpdatedFrom != nil {
+		q.Where("UpdateAt >= ?", *filter.UpdatedFrom)
+	}
+
+	if filter.UpdatedTo != nil {
+		q.Where("UpdateAt < ?", *filter.UpdatedTo)
+	}
+
+	if filter.SortBy != nil || filter.SortOrder != nil || filter.After != nil {
+		by := "CreateAt"
+		if filter.SortBy != nil && *filter.SortBy == models.UpdateAt {
 			by = "UpdateAt"
-		} else {
-			by = "CreateAt"
 		}
+		desc := filter.SortOrder == nil || *filter.SortOrder != models.SortAsc
 
-		if *filter.SortOrder == models.SortAsc {
-			order = "asc"
-		} else {
-			order = "desc"
+		// keyset pagination, OrderID is a tiebreaker for orders with same time
+		if filter.After != nil {
+			op := ">"
+			if desc {
+				op = "<"
+			}
+			q.Where(fmt.Sprintf("(%s, OrderID) %s (?, ?)", by, op), filter.After.At, filter.After.OrderID)
 		}
-		sortStmt := fmt.Sprintf("ORDER BY %s %s", by, order)
-		query = fmt.Sprintf("%s %s", query, sortStmt)
+		// first page is ordered the same way, otherwise orders with same time are skipped or repeated
+		q.OrderBy(by, desc).OrderBy("OrderID", desc)
 	}
 
 	if filter.Limit != nil {
-		limitStmt := fmt.Sprintf("Limit %d", *filter.Limit)
-		query = fmt.Sprintf("%s %s", query, limitStmt)
+		q.Limit(*filter.Limit)
 	}
 
 	if filter.Offset != nil {
-		offsetStmt := fmt.Sprintf("Offset %d", *filter.Offset)
-		query = fmt.Sprintf("%s %s", query, offsetStmt)
+		q.Offset(*filter.Offset)
 	}
 
-	rows, err := o.db.client.Query(query)
+	stmt, args := q.Build()
+
+	rows, err := o.db.client.QueryContext(ctx, stmt, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query %s, err: %w", query, err)
+		return fmt.Errorf("failed to query %s, err: %w", stmt, err)
 	}
-
-	var orders []*models.Order
+	defer rows.Close()
 
 	for rows.Next() {
 		var orderRow OrderRow
-		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt)
+		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt, &orderRow.FinalizedAt, &orderRow.Version)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan order row %w", err)
+			return fmt.Errorf("failed to scan order row %w", err)
+		}
+		if err := fn(orderRow.OrderRowToOrder()); err != nil {
+			return err
 		}
-		orders = append(orders, orderRow.OrderRowToOrder())
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to run get orders query: %w", err)
+		return fmt.Errorf("failed to run get orders query: %w", err)
 	}
-
-	return orders, nil
-}
-
-func addWhere(query string, stmt string) string {
-	if strings.Contains(query, "WHERE") {
-		stmt = fmt.Sprintf("AND %s", stmt)
-	} else {
-		stmt = fmt.Sprintf("WHERE %s", stmt)
-	}
-	return fmt.Sprintf("%s %s", query, stmt)
+	return nil
 }
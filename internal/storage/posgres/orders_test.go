@@ -1,6 +1,7 @@
 package posgres
 
 import (
+	"context"
 	"testing"
 	"time"
 	"webhooker/internal/services/models"
@@ -10,7 +11,7 @@ import (
 )
 
 var (
-	ordersColumn = []string{"OrderID", "UserId", "OrderStatus", "IsFinal", "CreateAt", "UpdateAt"}
+	ordersColumn = []string{"OrderID", "UserId", "OrderStatus", "IsFinal", "CreateAt", "UpdateAt", "FinalizedAt", "Version"}
 )
 
 func Test_GetOrder(t *testing.T) {
@@ -27,15 +28,134 @@ func Test_GetOrder(t *testing.T) {
 		IsFinal:  true,
 		CreateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
 		UpdateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		// finalized after cooldown
+		FinalizedAt: time.Date(2022, 10, 10, 12, 30, 30, 0, time.UTC),
+		Version:     3,
 	}
 	orderRow := sqlmock.NewRows(ordersColumn).
-		AddRow(expOrder.ID, expOrder.UserID, expOrder.Status, expOrder.IsFinal, expOrder.CreateAt, expOrder.UpdateAt)
+		AddRow(expOrder.ID, expOrder.UserID, expOrder.Status, expOrder.IsFinal, expOrder.CreateAt, expOrder.UpdateAt, expOrder.FinalizedAt, expOrder.Version)
 
-	mock.ExpectQuery(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt FROM Orders WHERE OrderID = \$1`).WithArgs(expOrder.ID).WillReturnRows(orderRow)
+	mock.ExpectQuery(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version FROM Orders WHERE OrderID = \$1`).WithArgs(expOrder.ID).WillReturnRows(orderRow)
 
 	storage := OrderStorage{db: &PgClient{db}}
 
-	order, err := storage.GetOrder(expOrder.ID)
+	order, err := storage.GetOrder(context.Background(), expOrder.ID)
 	assert.Nil(t, err)
 	assert.Equal(t, expOrder, order)
 }
+
+func Test_GetOrders_HostileInput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		userID    = "' OR '1'='1"
+		createdTo = time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC)
+		statuses  = []string{"chinazes') OR ('1'='1"}
+		isFinal   = true
+		limit     = 10
+		offset    = 0
+		sortBy    = models.CreateAt
+		sortOrder = models.SortDesc
+	)
+
+	mock.ExpectQuery(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version FROM Orders WHERE OrderStatus IN \(\$1\) AND UserID IN \(\$2\) AND OrderID IN \(\$3\) AND IsFinal = \$4 AND CreateAt < \$5 ORDER BY CreateAt DESC, OrderID DESC LIMIT \$6 OFFSET \$7`).
+		WithArgs(statuses[0], userID, userID, isFinal, createdTo, limit, offset).
+		WillReturnRows(sqlmock.NewRows(ordersColumn))
+
+	storage := OrderStorage{db: &PgClient{db}}
+
+	orders, err := storage.GetOrders(context.Background(), &models.OrderFilter{
+		Status:    statuses,
+		UserIDs:   []string{userID},
+		OrderIDs:  []string{userID},
+		CreatedTo: &createdTo,
+		IsFinal:   &isFinal,
+		Limit:     &limit,
+		Offset:    &offset,
+		SortBy:    &sortBy,
+		SortOrder: &sortOrder,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, orders)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetOrders_Cursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		isFinal   = false
+		limit     = 11
+		sortBy    = models.UpdateAt
+		sortOrder = models.SortAsc
+		after     = &models.OrderCursor{
+			SortBy:    sortBy,
+			SortOrder: sortOrder,
+			At:        time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+			OrderID:   "orderID",
+		}
+	)
+
+	mock.ExpectQuery(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version FROM Orders WHERE IsFinal = \$1 AND \(UpdateAt, OrderID\) > \(\$2, \$3\) ORDER BY UpdateAt ASC, OrderID ASC LIMIT \$4`).
+		WithArgs(isFinal, after.At, after.OrderID, limit).
+		WillReturnRows(sqlmock.NewRows(ordersColumn))
+
+	storage := OrderStorage{db: &PgClient{db}}
+
+	_, err = storage.GetOrders(context.Background(), &models.OrderFilter{
+		IsFinal:   &isFinal,
+		Limit:     &limit,
+		SortBy:    &sortBy,
+		SortOrder: &sortOrder,
+		After:     after,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetOrders_FirstPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		limit     = 2
+		sortBy    = models.UpdateAt
+		sortOrder = models.SortAsc
+		// orders with same time are ordered by id, so cursor of the last one doesn't skip or repeat them
+		updateAt = time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC)
+		exp      = []*models.Order{
+			{ID: "1", UserID: "userID", Status: models.PendingStatus, CreateAt: updateAt, UpdateAt: updateAt, Version: 1},
+			{ID: "2", UserID: "userID", Status: models.PendingStatus, CreateAt: updateAt, UpdateAt: updateAt, Version: 1},
+		}
+	)
+	rows := sqlmock.NewRows(ordersColumn)
+	for _, o := range exp {
+		rows.AddRow(o.ID, o.UserID, o.Status, o.IsFinal, o.CreateAt, o.UpdateAt, nil, o.Version)
+	}
+
+	mock.ExpectQuery(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version FROM Orders ORDER BY UpdateAt ASC, OrderID ASC LIMIT \$1`).
+		WithArgs(limit).
+		WillReturnRows(rows)
+
+	storage := OrderStorage{db: &PgClient{db}}
+
+	orders, err := storage.GetOrders(context.Background(), &models.OrderFilter{
+		Limit:     &limit,
+		SortBy:    &sortBy,
+		SortOrder: &sortOrder,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, exp, orders)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,8 +1,11 @@
 package posgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"webhooker/config"
 
 	_ "github.com/lib/pq"
@@ -10,7 +13,8 @@ import (
 
 const (
 	driverName = "postgres"
-	sslMode    = "disable"
+	// serverLockID is key of advisory lock which servers hold shared and commands changing orders hold exclusively
+	serverLockID = 0x77656268
 )
 
 type PgClient struct {
@@ -18,11 +22,23 @@ type PgClient struct {
 }
 
 func NewPgClient(cfg *config.PgCredentials) (*PgClient, error) {
-	connectionStr := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s", cfg.User, cfg.Password, cfg.Host, cfg.DbName, sslMode)
+	connectionStr := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     cfg.Host,
+		Path:     cfg.DbName,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}).String()
 	db, err := sql.Open(driverName, connectionStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db: %w", err)
 	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	if err = db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping db, %w", err)
@@ -36,3 +52,39 @@ func NewPgClient(cfg *config.PgCredentials) (*PgClient, error) {
 func (c *PgClient) Close() error {
 	return c.client.Close()
 }
+
+// Ping checks connection to db, it is used by readiness check
+func (c *PgClient) Ping(ctx context.Context) error {
+	return c.client.PingContext(ctx)
+}
+
+func (c *PgClient) Stats() sql.DBStats {
+	return c.client.Stats()
+}
+
+// TryLock takes advisory lock of servers on dedicated connection till release, servers share the lock.
+// ok is false if lock is held by other process in conflicting mode
+func (c *PgClient) TryLock(ctx context.Context, shared bool) (release func() error, ok bool, err error) {
+	lock, unlock := "pg_try_advisory_lock", "pg_advisory_unlock"
+	if shared {
+		lock, unlock = "pg_try_advisory_lock_shared", "pg_advisory_unlock_shared"
+	}
+	conn, err := c.client.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection, err: %w", err)
+	}
+	err = conn.QueryRowContext(ctx, "SELECT "+lock+"($1)", serverLockID).Scan(&ok)
+	if err != nil || !ok {
+		conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to take lock, err: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	return func() error {
+		// connection returns to pool, session lock isn't released with it
+		_, err := conn.ExecContext(context.Background(), "SELECT "+unlock+"($1)", serverLockID)
+		return errors.Join(err, conn.Close())
+	}, true, nil
+}
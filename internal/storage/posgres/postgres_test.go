@@ -0,0 +1,63 @@
+package posgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TryLock(t *testing.T) {
+	testCases := []struct {
+		name    string
+		shared  bool
+		locked  bool
+		lock    string
+		unlock  string
+		expLock bool
+	}{
+		{
+			name:    "server shares lock",
+			shared:  true,
+			locked:  true,
+			lock:    `SELECT pg_try_advisory_lock_shared\(\$1\)`,
+			unlock:  `SELECT pg_advisory_unlock_shared\(\$1\)`,
+			expLock: true,
+		},
+		{
+			name:    "command takes lock",
+			locked:  true,
+			lock:    `SELECT pg_try_advisory_lock\(\$1\)`,
+			unlock:  `SELECT pg_advisory_unlock\(\$1\)`,
+			expLock: true,
+		},
+		{
+			name: "failed. lock is held by server",
+			lock: `SELECT pg_try_advisory_lock\(\$1\)`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.Nil(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery(tc.lock).WithArgs(serverLockID).
+				WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(tc.locked))
+			if tc.unlock != "" {
+				mock.ExpectExec(tc.unlock).WithArgs(serverLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			client := &PgClient{db}
+			release, ok, err := client.TryLock(context.Background(), tc.shared)
+			require.Nil(t, err)
+			assert.Equal(t, tc.expLock, ok)
+			if ok {
+				assert.Nil(t, release())
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package posgres
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CountOrders_Bucket(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		from = time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC)
+		to   = time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)
+		// driver returns bucket in time zone of session
+		bucket = time.Date(2022, 10, 10, 14, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	)
+
+	mock.ExpectQuery(`SELECT date_trunc\('hour', o.CreateAt\), o.OrderStatus, o.IsFinal, COUNT\(\*\) FROM Orders o WHERE o.CreateAt >= \$1 AND o.CreateAt < \$2 AND o.UserID IN \(\$3\) GROUP BY 1, o.OrderStatus, o.IsFinal ORDER BY 1 ASC`).
+		WithArgs(from, to, "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"date_trunc", "OrderStatus", "IsFinal", "count"}).
+			AddRow(bucket, models.DoneStatus, true, 2))
+
+	storage := StatsStorage{db: &PgClient{db}}
+
+	counts, err := storage.CountOrders(context.Background(), &models.StatsFilter{From: &from, To: &to, UserIDs: []string{"user1"}, Bucket: models.BucketHour})
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.OrdersCount{
+		{Bucket: time.Date(2022, 10, 10, 11, 0, 0, 0, time.UTC), Status: models.DoneStatus, IsFinal: true, Count: 2},
+	}, counts)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_CountReached_Archived(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	from := time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT e.OrderStatus, COUNT\(DISTINCT e.OrderID\) FROM \(SELECT OrderID, OrderStatus FROM Events UNION ALL SELECT OrderID, OrderStatus FROM ArchivedStatuses\) e JOIN Orders o ON o.OrderID = e.OrderID WHERE o.CreateAt >= \$1 GROUP BY e.OrderStatus`).
+		WithArgs(from).
+		WillReturnRows(sqlmock.NewRows([]string{"OrderStatus", "count"}).
+			AddRow(models.OrderCreatedStatus, 3).
+			AddRow(models.DoneStatus, 1))
+
+	storage := StatsStorage{db: &PgClient{db}}
+
+	reached, err := storage.CountReached(context.Background(), &models.StatsFilter{From: &from})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{models.OrderCreatedStatus: 3, models.DoneStatus: 1}, reached)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetStatusPercentiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var (
+		from = time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC)
+		to   = time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)
+	)
+
+	mock.ExpectQuery(`WITH m AS \(SELECT o.OrderID FROM Orders o WHERE o.CreateAt >= \$1 AND o.CreateAt < \$2\) `+
+		`SELECT OrderStatus, COUNT\(\*\), percentile_disc\(0.5\) WITHIN GROUP \(ORDER BY Seconds\), percentile_disc\(0.9\) WITHIN GROUP \(ORDER BY Seconds\), percentile_disc\(0.99\) WITHIN GROUP \(ORDER BY Seconds\) `+
+		`FROM \(.* FROM Events e JOIN m ON m.OrderID = e.OrderID UNION ALL SELECT a.OrderStatus, a.Seconds FROM ArchivedStatuses a JOIN m ON m.OrderID = a.OrderID \) d `+
+		`WHERE Seconds IS NOT NULL GROUP BY OrderStatus`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"OrderStatus", "count", "p50", "p90", "p99"}).
+			AddRow(models.PendingStatus, 10, 5.0, 9.0, 10.5))
+
+	storage := StatsStorage{db: &PgClient{db}}
+
+	timeInStatus, err := storage.GetStatusPercentiles(context.Background(), &models.StatsFilter{From: &from, To: &to})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]models.Percentiles{
+		models.PendingStatus: {Count: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10500 * time.Millisecond},
+	}, timeInStatus)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_SaveArchive_KeepsStatuses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	archive := &models.EventArchive{
+		OrderID:     "1",
+		Key:         "archive/1.ndjson.gz",
+		EventsCount: 2,
+		ArchivedAt:  time.Date(2022, 11, 10, 11, 30, 30, 0, time.UTC),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO EventArchives\(OrderID, ArchiveKey, EventsCount, ArchivedAt\) VALUES\(\$1, \$2, \$3, \$4\)`).
+		WithArgs(archive.OrderID, archive.Key, archive.EventsCount, archive.ArchivedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO ArchivedStatuses\(OrderID, OrderStatus, Seconds\) SELECT OrderID, OrderStatus, EXTRACT\(EPOCH FROM \(LEAD\(UpdateAt\) OVER \(ORDER BY UpdateAt, EventID\) - UpdateAt\)\) FROM Events WHERE OrderID = \$1 AND EventID IN \(\$2, \$3\)`).
+		WithArgs(archive.OrderID, "1", "2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM Events WHERE OrderID = \$1 AND EventID IN \(\$2, \$3\)`).
+		WithArgs(archive.OrderID, "1", "2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	storage := ArchiveStorage{db: &PgClient{db}}
+
+	err = storage.SaveArchive(context.Background(), archive, []string{"1", "2"})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,45 @@
+package posgres
+
+import (
+	"os"
+	"testing"
+	"webhooker/config"
+	"webhooker/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// suite requires running postgres, for example from docker-compose:
+// TEST_PG_HOST=localhost TEST_PG_USER=test TEST_PG_PASS=test TEST_PG_DB_NAME=stream_data go test ./...
+func Test_StorageSuite(t *testing.T) {
+	cfg := &config.PgCredentials{
+		User:     os.Getenv("TEST_PG_USER"),
+		Password: os.Getenv("TEST_PG_PASS"),
+		Host:     os.Getenv("TEST_PG_HOST"),
+		DbName:   os.Getenv("TEST_PG_DB_NAME"),
+		SSLMode:  "disable",
+	}
+	if cfg.Host == "" {
+		t.Skip("TEST_PG_HOST isn't set")
+	}
+
+	client, err := NewPgClient(cfg)
+	require.Nil(t, err)
+	defer client.Close()
+
+	migrator, err := NewMigrator(client)
+	require.Nil(t, err)
+	_, err = migrator.Up()
+	require.Nil(t, err)
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		_, err := client.client.Exec("DELETE FROM EventArchives; DELETE FROM ArchivedStatuses; DELETE FROM Events; DELETE FROM Orders;")
+		require.Nil(t, err)
+		return storagetest.Storage{
+			Orders:   NewOrderStorage(client),
+			Events:   NewEventStorage(client),
+			Archives: NewArchiveStorage(client),
+			Stats:    NewStatsStorage(client),
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placeholder returns placeholder of n-th argument
+type Placeholder func(n int) string
+
+var (
+	// Dollar is postgres placeholder $1
+	Dollar Placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	// Question is sqlite placeholder ?1, it binds argument by number and not by position in statement
+	Question Placeholder = func(n int) string { return fmt.Sprintf("?%d", n) }
+)
+
+// Builder collects sql clauses and their arguments.
+// User input always goes to arguments, statements contain only placeholders.
+type Builder struct {
+	base        string
+	placeholder Placeholder
+	where       []string
+	groupBy     []string
+	orderBy     []string
+	limit       string
+	offset      string
+	args        []any
+}
+
+func New(base string) *Builder {
+	return &Builder{
+		base:        base,
+		placeholder: Dollar,
+	}
+}
+
+func (b *Builder) WithPlaceholder(p Placeholder) *Builder {
+	b.placeholder = p
+	return b
+}
+
+// Where adds condition joined by AND, every ? in condition is replaced by placeholder of next argument
+func (b *Builder) Where(cond string, args ...any) *Builder {
+	if strings.Count(cond, "?") != len(args) {
+		panic(fmt.Sprintf("query: condition %q expects %d args, got %d", cond, strings.Count(cond, "?"), len(args)))
+	}
+	var sb strings.Builder
+	i := 0
+	for _, r := range cond {
+		if r == '?' {
+			sb.WriteString(b.arg(args[i]))
+			i++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	b.where = append(b.where, sb.String())
+	return b
+}
+
+// WhereIn adds column IN (...) condition, empty values don't match any row
+func (b *Builder) WhereIn(column string, values []string) *Builder {
+	if len(values) == 0 {
+		b.where = append(b.where, "1 = 0")
+		return b
+	}
+	placeholders := make([]string, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, b.arg(v))
+	}
+	b.where = append(b.where, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	return b
+}
+
+// GroupBy adds group by columns, columns should be constants and not user input
+func (b *Builder) GroupBy(columns ...string) *Builder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// OrderBy adds sort by column, column should be a constant and not user input
+func (b *Builder) OrderBy(column string, desc bool) *Builder {
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	b.orderBy = append(b.orderBy, fmt.Sprintf("%s %s", column, order))
+	return b
+}
+
+func (b *Builder) Limit(limit int) *Builder {
+	b.limit = fmt.Sprintf("LIMIT %s", b.arg(limit))
+	return b
+}
+
+func (b *Builder) Offset(offset int) *Builder {
+	b.offset = fmt.Sprintf("OFFSET %s", b.arg(offset))
+	return b
+}
+
+func (b *Builder) Build() (string, []any) {
+	parts := []string{b.base}
+	if len(b.where) > 0 {
+		parts = append(parts, "WHERE "+strings.Join(b.where, " AND "))
+	}
+	if len(b.groupBy) > 0 {
+		parts = append(parts, "GROUP BY "+strings.Join(b.groupBy, ", "))
+	}
+	if len(b.orderBy) > 0 {
+		parts = append(parts, "ORDER BY "+strings.Join(b.orderBy, ", "))
+	}
+	if b.limit != "" {
+		parts = append(parts, b.limit)
+	}
+	if b.offset != "" {
+		parts = append(parts, b.offset)
+	}
+	return strings.Join(parts, " "), b.args
+}
+
+func (b *Builder) arg(v any) string {
+	b.args = append(b.args, v)
+	return b.placeholder(len(b.args))
+}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Builder(t *testing.T) {
+	testCases := []struct {
+		name    string
+		build   func() *Builder
+		expStmt string
+		expArgs []any
+	}{
+		{
+			name: "without clauses",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders")
+			},
+			expStmt: "SELECT * FROM Orders",
+		},
+		{
+			name: "all clauses",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders").
+					WhereIn("OrderStatus", []string{"failed", "chinazes"}).
+					Where("UserID = ?", "user").
+					Where("IsFinal = ?", true).
+					OrderBy("CreateAt", true).
+					Limit(10).
+					Offset(5)
+			},
+			expStmt: "SELECT * FROM Orders WHERE OrderStatus IN ($1, $2) AND UserID = $3 AND IsFinal = $4 ORDER BY CreateAt DESC LIMIT $5 OFFSET $6",
+			expArgs: []any{"failed", "chinazes", "user", true, 10, 5},
+		},
+		{
+			name: "several args in condition",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders").
+					Where("(CreateAt, OrderID) > (?, ?)", "2022-10-10", "id").
+					OrderBy("CreateAt", false)
+			},
+			expStmt: "SELECT * FROM Orders WHERE (CreateAt, OrderID) > ($1, $2) ORDER BY CreateAt ASC",
+			expArgs: []any{"2022-10-10", "id"},
+		},
+		{
+			name: "group by",
+			build: func() *Builder {
+				return New("SELECT OrderStatus, COUNT(*) FROM Orders").
+					Where("UserID = ?", "user").
+					GroupBy("OrderStatus", "IsFinal").
+					OrderBy("OrderStatus", false)
+			},
+			expStmt: "SELECT OrderStatus, COUNT(*) FROM Orders WHERE UserID = $1 GROUP BY OrderStatus, IsFinal ORDER BY OrderStatus ASC",
+			expArgs: []any{"user"},
+		},
+		{
+			name: "question placeholder",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders").
+					WithPlaceholder(Question).
+					Where("UserID = ?", "user").
+					Offset(5).
+					Limit(10)
+			},
+			expStmt: "SELECT * FROM Orders WHERE UserID = ?1 LIMIT ?3 OFFSET ?2",
+			expArgs: []any{"user", 5, 10},
+		},
+		{
+			name: "empty in",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders").WhereIn("OrderStatus", []string{})
+			},
+			expStmt: "SELECT * FROM Orders WHERE 1 = 0",
+		},
+		{
+			name: "hostile input is argument",
+			build: func() *Builder {
+				return New("SELECT * FROM Orders").
+					Where("UserID = ?", "' OR '1'='1").
+					WhereIn("OrderStatus", []string{"failed'); DROP TABLE Orders; --"})
+			},
+			expStmt: "SELECT * FROM Orders WHERE UserID = $1 AND OrderStatus IN ($2)",
+			expArgs: []any{"' OR '1'='1", "failed'); DROP TABLE Orders; --"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stmt, args := tc.build().Build()
+			assert.Equal(t, tc.expStmt, stmt)
+			assert.Equal(t, tc.expArgs, args)
+		})
+	}
+}
+
+func Test_Builder_WrongArgs(t *testing.T) {
+	assert.Panics(t, func() {
+		New("SELECT * FROM Orders").Where("UserID = ?")
+	})
+}
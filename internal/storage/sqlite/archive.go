@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
+)
+
+type ArchiveStorage struct {
+	db *SqliteClient
+}
+
+func NewArchiveStorage(client *SqliteClient) api.ArchiveStorage {
+	return &ArchiveStorage{
+		db: client,
+	}
+}
+
+func (a *ArchiveStorage) GetArchive(ctx context.Context, orderID string) (*models.EventArchive, error) {
+	query := "SELECT OrderID, ArchiveKey, EventsCount, ArchivedAt FROM EventArchives WHERE OrderID = ?1"
+
+	archive := &models.EventArchive{}
+	err := a.db.client.QueryRowContext(ctx, query, orderID).Scan(&archive.OrderID, &archive.Key, &archive.EventsCount, &archive.ArchivedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.EventArchive{}, nil
+		}
+		return nil, fmt.Errorf("failed to get archive %w", err)
+	}
+	return archive, nil
+}
+
+func (a *ArchiveStorage) GetArchives(ctx context.Context, orderIDs []string) ([]*models.EventArchive, error) {
+	stmt, args := query.New("SELECT OrderID, ArchiveKey, EventsCount, ArchivedAt FROM EventArchives").WithPlaceholder(query.Question).
+		WhereIn("OrderID", orderIDs).
+		Build()
+
+	rows, err := a.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archives %w", err)
+	}
+	defer rows.Close()
+
+	var archives []*models.EventArchive
+	for rows.Next() {
+		archive := &models.EventArchive{}
+		if err := rows.Scan(&archive.OrderID, &archive.Key, &archive.EventsCount, &archive.ArchivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan archive %w", err)
+		}
+		archives = append(archives, archive)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run archives query: %w", err)
+	}
+	return archives, nil
+}
+
+func (a *ArchiveStorage) GetArchiveCandidates(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	query := `SELECT o.OrderID FROM Orders o
+	WHERE o.IsFinal = 1 AND o.FinalizedAt < ?1
+	AND NOT EXISTS (SELECT 1 FROM EventArchives a WHERE a.OrderID = o.OrderID)
+	ORDER BY o.FinalizedAt ASC, o.OrderID ASC LIMIT ?2`
+
+	rows, err := a.db.client.QueryContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archive candidates %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan archive candidate %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run archive candidates query: %w", err)
+	}
+	return ids, nil
+}
+
+func (a *ArchiveStorage) SaveArchive(ctx context.Context, archive *models.EventArchive, eventIDs []string) error {
+	tx, err := a.db.client.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin archive transaction %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := "INSERT INTO EventArchives(OrderID, ArchiveKey, EventsCount, ArchivedAt) VALUES(?1, ?2, ?3, ?4)"
+	_, err = tx.ExecContext(ctx, insert, archive.OrderID, archive.Key, archive.EventsCount, archive.ArchivedAt.UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrAlreadyExist
+		}
+		return fmt.Errorf("failed to save archive, err: %w", err)
+	}
+
+	// time in status is kept for stats, the last event has no next one and gets NULL
+	stmt, args := query.New(`INSERT INTO ArchivedStatuses(OrderID, OrderStatus, Seconds)
+	SELECT OrderID, OrderStatus, (julianday(LEAD(UpdateAt) OVER (ORDER BY UpdateAt, EventID)) - julianday(UpdateAt)) * 86400.0 FROM Events`).WithPlaceholder(query.Question).
+		Where("OrderID = ?", archive.OrderID).
+		WhereIn("EventID", eventIDs).
+		Build()
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("failed to save archived statuses, err: %w", err)
+	}
+
+	// only archived events are removed, events saved after archive was written stay in table
+	stmt, args = query.New("DELETE FROM Events").WithPlaceholder(query.Question).
+		Where("OrderID = ?", archive.OrderID).
+		WhereIn("EventID", eventIDs).
+		Build()
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("failed to delete archived events, err: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit archive, err: %w", err)
+	}
+	return nil
+}
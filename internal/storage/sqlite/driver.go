@@ -0,0 +1,16 @@
+//go:build cgo
+
+package sqlite
+
+import "github.com/mattn/go-sqlite3"
+
+// errNoDriver is set if binary is built without sqlite driver
+var errNoDriver error
+
+func isUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
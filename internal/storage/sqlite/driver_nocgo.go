@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package sqlite
+
+import "errors"
+
+// sqlite driver requires cgo, binary built without cgo can use postgres or in-memory storage
+var errNoDriver = errors.New("sqlite storage isn't supported by binary built without cgo, build it with CGO_ENABLED=1")
+
+func isUniqueViolation(err error) bool {
+	return false
+}
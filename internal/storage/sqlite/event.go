@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
+)
+
+type EventRow struct {
+	EventID     string
+	OrderID     string
+	UserID      string
+	OrderStatus string
+	IsFinal     bool
+	IsSystem    bool
+	CreateAt    time.Time
+	UpdateAt    time.Time
+	ReceivedAt  sql.NullTime
+}
+
+func (e *EventRow) EventRowToEvent() *models.Event {
+	return &models.Event{
+		EventID:     e.EventID,
+		OrderID:     e.OrderID,
+		UserID:      e.UserID,
+		OrderStatus: e.OrderStatus,
+		IsFinal:     e.IsFinal,
+		IsSystem:    e.IsSystem,
+		CreateAt:    e.CreateAt,
+		UpdateAt:    e.UpdateAt,
+		ReceivedAt:  e.ReceivedAt.Time,
+	}
+}
+
+func (e *EventRow) EventRowFromEvent(event *models.Event) {
+	e.EventID = event.EventID
+	e.OrderID = event.OrderID
+	e.UserID = event.UserID
+	e.OrderStatus = event.OrderStatus
+	e.IsFinal = event.IsFinal
+	e.IsSystem = event.IsSystem
+	e.CreateAt = event.CreateAt.UTC()
+	e.UpdateAt = event.UpdateAt.UTC()
+	e.ReceivedAt = sql.NullTime{Time: event.ReceivedAt.UTC(), Valid: !event.ReceivedAt.IsZero()}
+}
+
+type EventStorage struct {
+	db *SqliteClient
+}
+
+func NewEventStorage(client *SqliteClient) api.EventStorage {
+	return &EventStorage{
+		db: client,
+	}
+}
+
+func (e *EventStorage) SaveEvent(ctx context.Context, event *models.Event) error {
+	var eventRow EventRow
+	eventRow.EventRowFromEvent(event)
+
+	query := "INSERT INTO Events(EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt) VALUES(?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)"
+	_, err := e.db.client.ExecContext(ctx, query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.IsSystem, eventRow.CreateAt, eventRow.UpdateAt, eventRow.ReceivedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrAlreadyExist
+		}
+		return fmt.Errorf("failed to save event, err: %w", err)
+	}
+	return nil
+}
+
+func (e *EventStorage) UpdateEvent(ctx context.Context, event *models.Event) error {
+	query := `UPDATE Events
+	SET EventID = ?1, OrderID = ?2, UserID = ?3, OrderStatus = ?4, IsFinal = ?5, IsSystem = ?6, CreateAt = ?7, UpdateAt = ?8, ReceivedAt = ?9
+	WHERE EventID = ?1`
+
+	var eventRow EventRow
+	eventRow.EventRowFromEvent(event)
+
+	_, err := e.db.client.ExecContext(ctx, query, eventRow.EventID, eventRow.OrderID, eventRow.UserID, eventRow.OrderStatus, eventRow.IsFinal, eventRow.IsSystem, eventRow.CreateAt, eventRow.UpdateAt, eventRow.ReceivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update event, err: %w", err)
+	}
+	return nil
+}
+
+func (e *EventStorage) DeleteEvent(ctx context.Context, eventID string) error {
+	_, err := e.db.client.ExecContext(ctx, "DELETE FROM Events WHERE EventID = ?1", eventID)
+	if err != nil {
+		return fmt.Errorf("failed to delete event, err: %w", err)
+	}
+	return nil
+}
+
+func (e *EventStorage) GetEvents(ctx context.Context, filter *models.EventsFilter) ([]*models.Event, error) {
+	q := query.New(`SELECT EventID, OrderID, UserID, OrderStatus, IsFinal, IsSystem, CreateAt, UpdateAt, ReceivedAt 
+	FROM Events`).WithPlaceholder(query.Question)
+
+	if filter.OrderID != nil {
+		q.Where("OrderID = ?", *filter.OrderID)
+	}
+
+	if filter.EventID != nil {
+		q.Where("EventID = ?", *filter.EventID)
+	}
+
+	if filter.OrderIDs != nil {
+		q.WhereIn("OrderID", filter.OrderIDs)
+	}
+
+	stmt, args := q.Build()
+
+	rows, err := e.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+
+	for rows.Next() {
+		var eventRow EventRow
+		err := rows.Scan(&eventRow.EventID, &eventRow.OrderID, &eventRow.UserID, &eventRow.OrderStatus, &eventRow.IsFinal, &eventRow.IsSystem, &eventRow.CreateAt, &eventRow.UpdateAt, &eventRow.ReceivedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event row %w", err)
+		}
+		events = append(events, eventRow.EventRowToEvent())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run get events query: %w", err)
+	}
+
+	return events, nil
+}
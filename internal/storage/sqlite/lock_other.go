@@ -0,0 +1,27 @@
+//go:build !unix
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TryLock creates lock file next to db till release. There is no flock, so lock is always exclusive:
+// only one server or command uses db at a time. ok is false if lock file exists,
+// file of crashed process stays and should be removed by hand
+func (c *SqliteClient) TryLock(ctx context.Context, shared bool) (release func() error, ok bool, err error) {
+	path := c.path + ".lock"
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to create lock file, err: %w", err)
+	}
+	return func() error {
+		return errors.Join(file.Close(), os.Remove(path))
+	}, true, nil
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// TryLock takes lock of servers on file next to db till release, servers share the lock.
+// ok is false if lock is held by other process in conflicting mode
+func (c *SqliteClient) TryLock(ctx context.Context, shared bool) (release func() error, ok bool, err error) {
+	file, err := os.OpenFile(c.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open lock file, err: %w", err)
+	}
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+	err = syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+	if err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to take lock, err: %w", err)
+	}
+	// lock is released with file
+	return file.Close, true, nil
+}
@@ -0,0 +1,20 @@
+CREATE TABLE Orders (
+    OrderID VARCHAR(37) PRIMARY KEY,
+    UserId VARCHAR(37) NOT NULL,
+    OrderStatus VARCHAR(50) NOT NULL,
+    IsFinal BOOLEAN NOT NULL,
+    CreateAt TIMESTAMP NOT NULL,
+    UpdateAt TIMESTAMP NOT NULL
+);
+
+CREATE TABLE Events (
+    EventID VARCHAR(37) PRIMARY KEY NOT NULL,
+    OrderID VARCHAR(37) NOT NULL,
+    UserID VARCHAR(37) NOT NULL,
+    OrderStatus VARCHAR(50) NOT NULL,
+    IsFinal BOOLEAN NOT NULL,
+    CreateAt TIMESTAMP NOT NULL,
+    UpdateAt TIMESTAMP NOT NULL
+);
+
+CREATE INDEX events_orderid ON Events (OrderID);
@@ -0,0 +1 @@
+ALTER TABLE Events ADD COLUMN IsSystem BOOLEAN NOT NULL DEFAULT false;
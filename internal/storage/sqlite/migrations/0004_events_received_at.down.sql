@@ -0,0 +1 @@
+ALTER TABLE Events DROP COLUMN ReceivedAt;
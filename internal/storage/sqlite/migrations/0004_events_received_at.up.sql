@@ -0,0 +1 @@
+ALTER TABLE Events ADD COLUMN ReceivedAt TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE Orders DROP COLUMN Version;
@@ -0,0 +1 @@
+ALTER TABLE Orders ADD COLUMN Version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- time in status of archived events, stats keep history of orders after their events are archived
+CREATE TABLE ArchivedStatuses (
+    OrderID VARCHAR(37) NOT NULL,
+    OrderStatus VARCHAR(50) NOT NULL,
+    Seconds DOUBLE PRECISION
+);
+
+CREATE INDEX archived_statuses_orderid ON ArchivedStatuses (OrderID);
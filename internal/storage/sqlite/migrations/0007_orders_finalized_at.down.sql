@@ -0,0 +1,2 @@
+DROP INDEX orders_finalizedat;
+ALTER TABLE Orders DROP COLUMN FinalizedAt;
@@ -0,0 +1,5 @@
+-- archive waits for retention after order became final, UpdateAt is time of provider
+ALTER TABLE Orders ADD COLUMN FinalizedAt TIMESTAMP;
+-- time of finalization of existing orders is unknown, retention starts from migration
+UPDATE Orders SET FinalizedAt = datetime('now') WHERE IsFinal AND FinalizedAt IS NULL;
+CREATE INDEX orders_finalizedat ON Orders (FinalizedAt);
@@ -0,0 +1,20 @@
+-- test orders, time has the same format as written by driver
+INSERT INTO Orders (OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt)
+VALUES('97a96c29-7631-4cbc-9559-f8866fb03392', '2c127d70-3b9b-4743-9c2e-74b9f617029f',
+ 'cool_order_created', false, '2022-10-10 11:30:30+00:00', '2022-10-10 11:30:30+00:00')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO Orders (OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt)
+VALUES('97a96c29-7631-4cbc-9559-f8866fb03393', '3c127d70-3b9b-4743-9c2e-74b9f617029f',
+'sbu_verification_pending', false, '2022-10-10 11:30:31+00:00', '2022-10-10 11:30:35+00:00')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO Orders (OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt)
+VALUES('97a96c29-7631-4cbc-9559-f8866fb03394', '3c127d70-3b9b-4743-9c2e-74b9f617029f',
+'confirmed_by_mayor', false, '2022-10-10 11:30:31+00:00', '2022-10-10 11:30:40+00:00')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO Orders (OrderID, UserID, OrderStatus, IsFinal, CreateAt, UpdateAt)
+VALUES('97a96c29-7631-4cbc-9559-f8866fb03395', '4c127d70-3b9b-4743-9c2e-74b9f617029f',
+'chinazes', true, '2022-10-10 11:30:30+00:00', '2022-10-10 11:30:45+00:00')
+ON CONFLICT DO NOTHING;
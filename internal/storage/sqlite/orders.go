@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
+)
+
+type OrderRow struct {
+	OrderID     string
+	UserID      string
+	OrderStatus string
+	IsFinal     bool
+	CreateAt    time.Time
+	UpdateAt    time.Time
+	FinalizedAt sql.NullTime
+	Version     int
+}
+
+func (o *OrderRow) OrderRowToOrder() *models.Order {
+	return &models.Order{
+		ID:          o.OrderID,
+		UserID:      o.UserID,
+		Status:      o.OrderStatus,
+		IsFinal:     o.IsFinal,
+		CreateAt:    o.CreateAt,
+		UpdateAt:    o.UpdateAt,
+		FinalizedAt: o.FinalizedAt.Time,
+		Version:     o.Version,
+	}
+}
+
+// sqlite keeps time as text, so all time is stored in UTC to keep comparison correct
+func (o *OrderRow) OrderRowFromOrder(order *models.Order) {
+	o.OrderID = order.ID
+	o.UserID = order.UserID
+	o.OrderStatus = order.Status
+	o.IsFinal = order.IsFinal
+	o.CreateAt = order.CreateAt.UTC()
+	o.UpdateAt = order.UpdateAt.UTC()
+	o.FinalizedAt = sql.NullTime{Time: order.FinalizedAt.UTC(), Valid: !order.FinalizedAt.IsZero()}
+	o.Version = order.Version
+}
+
+type OrderStorage struct {
+	db *SqliteClient
+}
+
+func NewOrderStorage(client *SqliteClient) api.OrderStorage {
+	return &OrderStorage{
+		db: client,
+	}
+}
+
+func (o *OrderStorage) GetOrder(ctx context.Context, id string) (*models.Order, error) {
+	query := `SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version 
+	FROM Orders
+	WHERE OrderID = ?1`
+
+	rows, err := o.db.client.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order, err: %w", err)
+	}
+	defer rows.Close()
+
+	var orderRow OrderRow
+	for rows.Next() {
+		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt, &orderRow.FinalizedAt, &orderRow.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order row %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run get order query: %w", err)
+	}
+
+	return orderRow.OrderRowToOrder(), nil
+}
+
+func (o *OrderStorage) SaveOrder(ctx context.Context, order *models.Order) error {
+	query := `INSERT INTO Orders (OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version)
+	VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, 1)`
+
+	var orderRow OrderRow
+	orderRow.OrderRowFromOrder(order)
+
+	_, err := o.db.client.ExecContext(ctx, query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt, orderRow.FinalizedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrAlreadyExist
+		}
+		return fmt.Errorf("failed to insert order, err: %w", err)
+	}
+	return nil
+}
+
+func (o *OrderStorage) UpdateOrder(ctx context.Context, order *models.Order) error {
+	query := `UPDATE Orders
+	SET OrderID = ?1, UserId = ?2, OrderStatus = ?3, IsFinal = ?4, CreateAt = ?5, UpdateAt = ?6, FinalizedAt = ?8, Version = Version + 1
+	WHERE OrderID = ?1 AND Version = ?7`
+
+	var orderRow OrderRow
+	orderRow.OrderRowFromOrder(order)
+
+	res, err := o.db.client.ExecContext(ctx, query, orderRow.OrderID, orderRow.UserID, orderRow.OrderStatus, orderRow.IsFinal, orderRow.CreateAt, orderRow.UpdateAt, orderRow.Version, orderRow.FinalizedAt)
+	if err != nil {
+		return fmt.Errorf("failed to exec update order, err: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get updated rows, err: %w", err)
+	}
+	// order was changed or removed after it was read
+	if updated == 0 {
+		return models.ErrVersionConflict
+	}
+	return nil
+}
+
+func (o *OrderStorage) GetOrders(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := o.IterateOrders(ctx, filter, func(order *models.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// IterateOrders scans rows one by one, so memory doesn't depend on number of orders
+func (o *OrderStorage) IterateOrders(ctx context.Context, filter *models.OrderFilter, fn func(*models.Order) error) error {
+	q := query.New(`SELECT OrderID, UserId, OrderStatus, IsFinal, CreateAt, UpdateAt, FinalizedAt, Version 
+	FROM Orders`).WithPlaceholder(query.Question)
+
+	if filter.Status != nil {
+		q.WhereIn("OrderStatus", filter.Status)
+	}
+
+	if filter.UserIDs != nil {
+		q.WhereIn("UserID", filter.UserIDs)
+	}
+
+	if filter.OrderIDs != nil {
+		q.WhereIn("OrderID", filter.OrderIDs)
+	}
+
+	if filter.IsFinal != nil {
+		q.Where("IsFinal = ?", *filter.IsFinal)
+	}
+
+	if filter.CreatedFrom != nil {
+		q.Where("CreateAt >= ?", filter.CreatedFrom.UTC())
+	}
+
+	if filter.CreatedTo != nil {
+		q.Where("CreateAt < ?", filter.CreatedTo.UTC())
+	}
+
+	if filter.UpdatedFrom != nil {
+		q.Where("UpdateAt >= ?", filter.UpdatedFrom.UTC())
+	}
+
+	if filter.UpdatedTo != nil {
+		q.Where("UpdateAt < ?", filter.UpdatedTo.UTC())
+	}
+
+	if filter.SortBy != nil || filter.SortOrder != nil || filter.After != nil {
+		by := "CreateAt"
+		if filter.SortBy != nil && *filter.SortBy == models.UpdateAt {
+			by = "UpdateAt"
+		}
+		desc := filter.SortOrder == nil || *filter.SortOrder != models.SortAsc
+
+		// keyset pagination, OrderID is a tiebreaker for orders with same time
+		if filter.After != nil {
+			op := ">"
+			if desc {
+				op = "<"
+			}
+			q.Where(fmt.Sprintf("(%s, OrderID) %s (?, ?)", by, op), filter.After.At.UTC(), filter.After.OrderID)
+		}
+		// first page is ordered the same way, otherwise orders with same time are skipped or repeated
+		q.OrderBy(by, desc).OrderBy("OrderID", desc)
+	}
+
+	if filter.Limit != nil {
+		q.Limit(*filter.Limit)
+	}
+
+	if filter.Offset != nil {
+		// sqlite doesn't support OFFSET without LIMIT
+		if filter.Limit == nil {
+			q.Limit(-1)
+		}
+		q.Offset(*filter.Offset)
+	}
+
+	stmt, args := q.Build()
+
+	rows, err := o.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query %s, err: %w", stmt, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderRow OrderRow
+		err := rows.Scan(&orderRow.OrderID, &orderRow.UserID, &orderRow.OrderStatus, &orderRow.IsFinal, &orderRow.CreateAt, &orderRow.UpdateAt, &orderRow.FinalizedAt, &orderRow.Version)
+		if err != nil {
+			return fmt.Errorf("failed to scan order row %w", err)
+		}
+		if err := fn(orderRow.OrderRowToOrder()); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to run get orders query: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"webhooker/config"
+)
+
+const (
+	driverName = "sqlite3"
+	// wait for lock instead of failing with SQLITE_BUSY, WAL allows reads during write
+	connectionParams = "_busy_timeout=5000&_journal_mode=WAL"
+)
+
+type SqliteClient struct {
+	client *sql.DB
+	path   string
+}
+
+func NewSqliteClient(cfg *config.SqliteConfig) (*SqliteClient, error) {
+	if errNoDriver != nil {
+		return nil, errNoDriver
+	}
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create db directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open(driverName, fmt.Sprintf("file:%s?%s", cfg.Path, connectionParams))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping db, %w", err)
+	}
+
+	return &SqliteClient{
+		client: db,
+		path:   cfg.Path,
+	}, nil
+}
+
+func (c *SqliteClient) Close() error {
+	return c.client.Close()
+}
+
+// Ping checks connection to db, it is used by readiness check
+func (c *SqliteClient) Ping(ctx context.Context) error {
+	return c.client.PingContext(ctx)
+}
+
+func (c *SqliteClient) Stats() sql.DBStats {
+	return c.client.Stats()
+}
@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+	"webhooker/internal/storage/query"
+)
+
+// bucketLayout is format of strftime bucket
+const bucketLayout = "2006-01-02T15:04:05Z"
+
+type StatsStorage struct {
+	db *SqliteClient
+}
+
+func NewStatsStorage(client *SqliteClient) api.StatsStorage {
+	return &StatsStorage{
+		db: client,
+	}
+}
+
+func (s *StatsStorage) CountOrders(ctx context.Context, filter *models.StatsFilter) ([]*models.OrdersCount, error) {
+	var q *query.Builder
+	if filter.Bucket == models.BucketNone {
+		q = query.New("SELECT NULL, o.OrderStatus, o.IsFinal, COUNT(*) FROM Orders o").
+			GroupBy("o.OrderStatus", "o.IsFinal")
+	} else {
+		// bucket is validated by service, only constant goes to statement
+		q = query.New(fmt.Sprintf("SELECT strftime('%s', o.CreateAt), o.OrderStatus, o.IsFinal, COUNT(*) FROM Orders o", bucketFormat(filter.Bucket))).
+			GroupBy("1", "o.OrderStatus", "o.IsFinal").
+			OrderBy("1", false)
+	}
+	q.WithPlaceholder(query.Question)
+	statsWhere(q, filter)
+	stmt, args := q.Build()
+
+	rows, err := s.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders count %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*models.OrdersCount
+	for rows.Next() {
+		var (
+			count  models.OrdersCount
+			bucket sql.NullString
+		)
+		if err := rows.Scan(&bucket, &count.Status, &count.IsFinal, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan orders count %w", err)
+		}
+		if bucket.Valid {
+			count.Bucket, err = time.Parse(bucketLayout, bucket.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse bucket %w", err)
+			}
+		}
+		counts = append(counts, &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run orders count query: %w", err)
+	}
+	return counts, nil
+}
+
+func (s *StatsStorage) CountReached(ctx context.Context, filter *models.StatsFilter) (map[string]int, error) {
+	// statuses of archived events are kept in ArchivedStatuses
+	q := query.New(`SELECT e.OrderStatus, COUNT(DISTINCT e.OrderID)
+	FROM (SELECT OrderID, OrderStatus FROM Events UNION ALL SELECT OrderID, OrderStatus FROM ArchivedStatuses) e
+	JOIN Orders o ON o.OrderID = e.OrderID`).
+		WithPlaceholder(query.Question).
+		GroupBy("e.OrderStatus")
+	statsWhere(q, filter)
+	stmt, args := q.Build()
+
+	rows, err := s.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reached statuses %w", err)
+	}
+	defer rows.Close()
+
+	reached := make(map[string]int)
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reached status %w", err)
+		}
+		reached[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run reached statuses query: %w", err)
+	}
+	return reached, nil
+}
+
+func (s *StatsStorage) GetStatusPercentiles(ctx context.Context, filter *models.StatsFilter) (map[string]models.Percentiles, error) {
+	q := query.New("SELECT o.OrderID FROM Orders o").WithPlaceholder(query.Question)
+	statsWhere(q, filter)
+	matched, args := q.Build()
+	// times are stored as text, julianday converts them to days.
+	// sqlite has no percentile functions, nearest rank is row number ceil(p * count / 100) in status
+	stmt := fmt.Sprintf(`WITH m AS (%s),
+	d AS (
+		SELECT e.OrderStatus, (julianday(LEAD(e.UpdateAt) OVER (PARTITION BY e.OrderID ORDER BY e.UpdateAt, e.EventID)) - julianday(e.UpdateAt)) * 86400.0 AS Seconds
+		FROM Events e JOIN m ON m.OrderID = e.OrderID
+		UNION ALL
+		SELECT a.OrderStatus, a.Seconds FROM ArchivedStatuses a JOIN m ON m.OrderID = a.OrderID
+	),
+	r AS (
+		SELECT OrderStatus, Seconds,
+		ROW_NUMBER() OVER (PARTITION BY OrderStatus ORDER BY Seconds) AS Rank,
+		COUNT(*) OVER (PARTITION BY OrderStatus) AS Total
+		FROM d WHERE Seconds IS NOT NULL
+	)
+	SELECT OrderStatus, MAX(Total),
+	MAX(CASE WHEN Rank = (50 * Total + 99) / 100 THEN Seconds END),
+	MAX(CASE WHEN Rank = (90 * Total + 99) / 100 THEN Seconds END),
+	MAX(CASE WHEN Rank = (99 * Total + 99) / 100 THEN Seconds END)
+	FROM r GROUP BY OrderStatus`, matched)
+
+	rows, err := s.db.client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status percentiles %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]models.Percentiles)
+	for rows.Next() {
+		var (
+			status        string
+			p             models.Percentiles
+			p50, p90, p99 float64
+		)
+		if err := rows.Scan(&status, &p.Count, &p50, &p90, &p99); err != nil {
+			return nil, fmt.Errorf("failed to scan status percentiles %w", err)
+		}
+		p.P50, p.P90, p.P99 = seconds(p50), seconds(p90), seconds(p99)
+		result[status] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run status percentiles query: %w", err)
+	}
+	return result, nil
+}
+
+// seconds rounds duration to milliseconds, julianday keeps milliseconds only
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
+
+func statsWhere(q *query.Builder, filter *models.StatsFilter) {
+	if filter.From != nil {
+		q.Where("o.CreateAt >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		q.Where("o.CreateAt < ?", filter.To.UTC())
+	}
+	if filter.UserIDs != nil {
+		q.WhereIn("o.UserID", filter.UserIDs)
+	}
+}
+
+func bucketFormat(bucket models.Bucket) string {
+	if bucket == models.BucketHour {
+		return "%Y-%m-%dT%H:00:00Z"
+	}
+	return "%Y-%m-%dT00:00:00Z"
+}
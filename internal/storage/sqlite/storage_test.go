@@ -0,0 +1,90 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"webhooker/config"
+	"webhooker/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StorageSuite(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		client, err := NewSqliteClient(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+		require.Nil(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		migrator, err := NewMigrator(client)
+		require.Nil(t, err)
+		_, err = migrator.Up()
+		require.Nil(t, err)
+
+		return storagetest.Storage{
+			Orders:   NewOrderStorage(client),
+			Events:   NewEventStorage(client),
+			Archives: NewArchiveStorage(client),
+			Stats:    NewStatsStorage(client),
+		}
+	})
+}
+
+func Test_Migrations(t *testing.T) {
+	client, err := NewSqliteClient(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	require.Nil(t, err)
+	defer client.Close()
+
+	migrator, err := NewMigrator(client)
+	require.Nil(t, err)
+
+	count, err := migrator.Up()
+	require.Nil(t, err)
+	require.Equal(t, migrator.Latest(), count)
+	require.Nil(t, migrator.Check())
+	require.Nil(t, migrator.Seed())
+
+	count, err = migrator.Down(migrator.Latest())
+	require.Nil(t, err)
+	require.Equal(t, migrator.Latest(), count)
+}
+
+func Test_TryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	server1, err := NewSqliteClient(&config.SqliteConfig{Path: path})
+	require.Nil(t, err)
+	defer server1.Close()
+	server2, err := NewSqliteClient(&config.SqliteConfig{Path: path})
+	require.Nil(t, err)
+	defer server2.Close()
+	command, err := NewSqliteClient(&config.SqliteConfig{Path: path})
+	require.Nil(t, err)
+	defer command.Close()
+
+	release1, ok, err := server1.TryLock(context.Background(), true)
+	require.Nil(t, err)
+	require.True(t, ok)
+	release2, ok, err := server2.TryLock(context.Background(), true)
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	// command waits for all servers
+	_, ok, err = command.TryLock(context.Background(), false)
+	require.Nil(t, err)
+	require.False(t, ok)
+	require.Nil(t, release1())
+	_, ok, err = command.TryLock(context.Background(), false)
+	require.Nil(t, err)
+	require.False(t, ok)
+	require.Nil(t, release2())
+
+	release, ok, err := command.TryLock(context.Background(), false)
+	require.Nil(t, err)
+	require.True(t, ok)
+	_, ok, err = server1.TryLock(context.Background(), true)
+	require.Nil(t, err)
+	require.False(t, ok)
+	require.Nil(t, release())
+}
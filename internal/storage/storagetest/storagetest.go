@@ -0,0 +1,583 @@
+// Package storagetest contains test suite which every storage implementation should pass
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+	"webhooker/internal/services/models"
+	"webhooker/internal/storage/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Storage is a set of storages which share same database
+type Storage struct {
+	Orders   api.OrderStorage
+	Events   api.EventStorage
+	Archives api.ArchiveStorage
+	Stats    api.StatsStorage
+}
+
+// Factory returns empty storages
+type Factory func(t *testing.T) Storage
+
+var (
+	firstOrder = &models.Order{
+		ID:       "1",
+		UserID:   "user1",
+		Status:   models.OrderCreatedStatus,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 50, 0, time.UTC),
+		Version:  1,
+	}
+	secondOrder = &models.Order{
+		ID:       "2",
+		UserID:   "user2",
+		Status:   models.PendingStatus,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 40, 0, time.UTC),
+		Version:  1,
+	}
+	thirdOrder = &models.Order{
+		ID:       "3",
+		UserID:   "user1",
+		Status:   models.FailedStatus,
+		IsFinal:  true,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 32, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 45, 0, time.UTC),
+		// finalized by service after timeout
+		FinalizedAt: time.Date(2022, 10, 11, 11, 30, 45, 0, time.UTC),
+		Version:     1,
+	}
+	// same CreateAt as thirdOrder
+	fourthOrder = &models.Order{
+		ID:       "4",
+		UserID:   "user2",
+		Status:   models.DoneStatus,
+		CreateAt: time.Date(2022, 10, 10, 11, 30, 32, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 11, 30, 55, 0, time.UTC),
+		Version:  1,
+	}
+	allOrders = []*models.Order{firstOrder, secondOrder, thirdOrder, fourthOrder}
+
+	createdEvent = &models.Event{
+		EventID:     "1",
+		OrderID:     "1",
+		UserID:      "user1",
+		OrderStatus: models.OrderCreatedStatus,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		ReceivedAt:  time.Date(2022, 10, 10, 11, 30, 32, 0, time.UTC),
+	}
+	failedEvent = &models.Event{
+		EventID:     "2",
+		OrderID:     "1",
+		UserID:      "user1",
+		OrderStatus: models.FailedStatus,
+		IsFinal:     true,
+		IsSystem:    true,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 30, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 40, 0, time.UTC),
+	}
+	otherOrderEvent = &models.Event{
+		EventID:     "3",
+		OrderID:     "2",
+		UserID:      "user2",
+		OrderStatus: models.OrderCreatedStatus,
+		CreateAt:    time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC),
+		UpdateAt:    time.Date(2022, 10, 10, 11, 30, 31, 0, time.UTC),
+	}
+)
+
+func Run(t *testing.T, newStorage Factory) {
+	t.Run("GetOrder", func(t *testing.T) {
+		orders := newStorage(t).Orders
+		saveOrders(t, orders)
+
+		order, err := orders.GetOrder(context.Background(), secondOrder.ID)
+		assert.Nil(t, err)
+		assertOrders(t, []*models.Order{secondOrder}, []*models.Order{order})
+
+		// absent order is empty
+		order, err = orders.GetOrder(context.Background(), "unknown")
+		assert.Nil(t, err)
+		assert.Equal(t, "", order.ID)
+	})
+
+	t.Run("SaveOrder duplicate", func(t *testing.T) {
+		orders := newStorage(t).Orders
+		saveOrders(t, orders)
+
+		err := orders.SaveOrder(context.Background(), firstOrder)
+		assert.Equal(t, models.ErrAlreadyExist, err)
+	})
+
+	t.Run("UpdateOrder", func(t *testing.T) {
+		orders := newStorage(t).Orders
+		saveOrders(t, orders)
+
+		updated := *firstOrder
+		updated.Status = models.PendingStatus
+		updated.UpdateAt = updated.UpdateAt.Add(time.Minute)
+		assert.Nil(t, orders.UpdateOrder(context.Background(), &updated))
+
+		// stale version
+		stale := *firstOrder
+		stale.Status = models.FailedStatus
+		assert.Equal(t, models.ErrVersionConflict, orders.UpdateOrder(context.Background(), &stale))
+
+		absent := *firstOrder
+		absent.ID = "unknown"
+		assert.Equal(t, models.ErrVersionConflict, orders.UpdateOrder(context.Background(), &absent))
+
+		order, err := orders.GetOrder(context.Background(), firstOrder.ID)
+		assert.Nil(t, err)
+		updated.Version = 2
+		assertOrders(t, []*models.Order{&updated}, []*models.Order{order})
+	})
+
+	t.Run("GetOrders", func(t *testing.T) {
+		testGetOrders(t, newStorage)
+	})
+
+	t.Run("GetOrders pages", func(t *testing.T) {
+		testPages(t, newStorage)
+	})
+
+	t.Run("IterateOrders", func(t *testing.T) {
+		orders := newStorage(t).Orders
+		saveOrders(t, orders)
+
+		byCreate, orderAsc := models.CreateAt, models.SortAsc
+		filter := &models.OrderFilter{
+			SortBy:    &byCreate,
+			SortOrder: &orderAsc,
+			After:     &models.OrderCursor{At: firstOrder.CreateAt, OrderID: firstOrder.ID},
+		}
+		var res []*models.Order
+		err := orders.IterateOrders(context.Background(), filter, func(order *models.Order) error {
+			res = append(res, order)
+			return nil
+		})
+		assert.Nil(t, err)
+		assertOrders(t, []*models.Order{secondOrder, thirdOrder, fourthOrder}, res)
+
+		// callback error stops iteration
+		stop := errors.New("stop")
+		res = nil
+		err = orders.IterateOrders(context.Background(), filter, func(order *models.Order) error {
+			res = append(res, order)
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assertOrders(t, []*models.Order{secondOrder}, res)
+	})
+
+	t.Run("Archives", func(t *testing.T) {
+		testArchives(t, newStorage)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		testStats(t, newStorage)
+	})
+
+	t.Run("Stats percentiles", func(t *testing.T) {
+		testPercentiles(t, newStorage)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		events := newStorage(t).Events
+		for _, e := range []*models.Event{createdEvent, failedEvent, otherOrderEvent} {
+			require.Nil(t, events.SaveEvent(context.Background(), e))
+		}
+
+		assert.Equal(t, models.ErrAlreadyExist, events.SaveEvent(context.Background(), createdEvent))
+
+		orderID := createdEvent.OrderID
+		res, err := events.GetEvents(context.Background(), &models.EventsFilter{OrderID: &orderID})
+		assert.Nil(t, err)
+		assertEvents(t, []*models.Event{createdEvent, failedEvent}, res)
+
+		res, err = events.GetEvents(context.Background(), &models.EventsFilter{OrderIDs: []string{orderID, otherOrderEvent.OrderID}})
+		assert.Nil(t, err)
+		assertEvents(t, []*models.Event{createdEvent, failedEvent, otherOrderEvent}, res)
+
+		updated := *createdEvent
+		updated.IsFinal = true
+		assert.Nil(t, events.UpdateEvent(context.Background(), &updated))
+
+		eventID := createdEvent.EventID
+		res, err = events.GetEvents(context.Background(), &models.EventsFilter{EventID: &eventID})
+		assert.Nil(t, err)
+		assertEvents(t, []*models.Event{&updated}, res)
+
+		assert.Nil(t, events.DeleteEvent(context.Background(), eventID))
+		res, err = events.GetEvents(context.Background(), &models.EventsFilter{OrderID: &orderID})
+		assert.Nil(t, err)
+		assertEvents(t, []*models.Event{failedEvent}, res)
+	})
+}
+
+func testGetOrders(t *testing.T, newStorage Factory) {
+	var (
+		userID    = "user1"
+		isFinal   = false
+		limit     = 2
+		offset    = 1
+		byCreate  = models.CreateAt
+		byUpdate  = models.UpdateAt
+		orderAsc  = models.SortAsc
+		orderDesc = models.SortDesc
+		statuses  = []string{models.PendingStatus, models.FailedStatus}
+		at        = func(sec int) *time.Time {
+			t := time.Date(2022, 10, 10, 11, 30, sec, 0, time.UTC)
+			return &t
+		}
+	)
+
+	testCases := []struct {
+		name   string
+		filter *models.OrderFilter
+		exp    []*models.Order
+	}{
+		{
+			name:   "by statuses",
+			filter: &models.OrderFilter{Status: statuses, SortBy: &byCreate, SortOrder: &orderAsc},
+			exp:    []*models.Order{secondOrder, thirdOrder},
+		},
+		{
+			name:   "empty statuses",
+			filter: &models.OrderFilter{Status: []string{}},
+			exp:    nil,
+		},
+		{
+			name:   "by user and isFinal",
+			filter: &models.OrderFilter{UserIDs: []string{userID}, IsFinal: &isFinal},
+			exp:    []*models.Order{firstOrder},
+		},
+		{
+			name:   "by users",
+			filter: &models.OrderFilter{UserIDs: []string{"user1", "user2"}, SortBy: &byUpdate, SortOrder: &orderAsc},
+			exp:    []*models.Order{secondOrder, thirdOrder, firstOrder, fourthOrder},
+		},
+		{
+			name:   "by order ids",
+			filter: &models.OrderFilter{OrderIDs: []string{"2", "4", "unknown"}, SortBy: &byUpdate, SortOrder: &orderAsc},
+			exp:    []*models.Order{secondOrder, fourthOrder},
+		},
+		{
+			name:   "by statuses and isFinal",
+			filter: &models.OrderFilter{Status: []string{models.FailedStatus, models.DoneStatus}, IsFinal: &isFinal},
+			exp:    []*models.Order{fourthOrder},
+		},
+		{
+			name:   "created range excludes end",
+			filter: &models.OrderFilter{CreatedFrom: at(31), CreatedTo: at(32)},
+			exp:    []*models.Order{secondOrder},
+		},
+		{
+			name:   "updated range",
+			filter: &models.OrderFilter{UpdatedFrom: at(45), UpdatedTo: at(55), SortBy: &byUpdate, SortOrder: &orderAsc},
+			exp:    []*models.Order{thirdOrder, firstOrder},
+		},
+		{
+			name:   "sort by update_at desc",
+			filter: &models.OrderFilter{SortBy: &byUpdate, SortOrder: &orderDesc},
+			exp:    []*models.Order{fourthOrder, firstOrder, thirdOrder, secondOrder},
+		},
+		{
+			name:   "limit and offset",
+			filter: &models.OrderFilter{SortBy: &byUpdate, SortOrder: &orderAsc, Limit: &limit, Offset: &offset},
+			exp:    []*models.Order{thirdOrder, firstOrder},
+		},
+		{
+			name: "after cursor with same time desc",
+			filter: &models.OrderFilter{
+				SortBy:    &byCreate,
+				SortOrder: &orderDesc,
+				After:     &models.OrderCursor{At: fourthOrder.CreateAt, OrderID: fourthOrder.ID},
+			},
+			exp: []*models.Order{thirdOrder, secondOrder, firstOrder},
+		},
+		{
+			name: "after cursor with same time asc",
+			filter: &models.OrderFilter{
+				SortBy:    &byCreate,
+				SortOrder: &orderAsc,
+				Limit:     &limit,
+				After:     &models.OrderCursor{At: secondOrder.CreateAt, OrderID: secondOrder.ID},
+			},
+			exp: []*models.Order{thirdOrder, fourthOrder},
+		},
+		{
+			name:   "first page with same time",
+			filter: &models.OrderFilter{SortBy: &byCreate, SortOrder: &orderDesc, Limit: &limit},
+			exp:    []*models.Order{fourthOrder, thirdOrder},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orders := newStorage(t).Orders
+			saveOrders(t, orders)
+
+			res, err := orders.GetOrders(context.Background(), tc.filter)
+			assert.Nil(t, err)
+			assertOrders(t, tc.exp, res)
+		})
+	}
+}
+
+// testPages reads all orders by cursor of the last order of page, orders with same time are neither skipped nor repeated
+func testPages(t *testing.T, newStorage Factory) {
+	limit := 1
+	for _, sortOrder := range []models.SortOrder{models.SortAsc, models.SortDesc} {
+		t.Run(string(sortOrder), func(t *testing.T) {
+			orders := newStorage(t).Orders
+			saveOrders(t, orders)
+
+			byCreate := models.CreateAt
+			filter := &models.OrderFilter{SortBy: &byCreate, SortOrder: &sortOrder, Limit: &limit}
+			seen := make(map[string]int)
+			for range allOrders {
+				page, err := orders.GetOrders(context.Background(), filter)
+				require.Nil(t, err)
+				require.Len(t, page, 1)
+				last := page[0]
+				seen[last.ID]++
+				filter.After = &models.OrderCursor{SortBy: byCreate, SortOrder: sortOrder, At: last.CreateAt, OrderID: last.ID}
+			}
+			assert.Len(t, seen, len(allOrders))
+			for id, count := range seen {
+				assert.Equal(t, 1, count, "order %s", id)
+			}
+		})
+	}
+}
+
+func testArchives(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	saveOrders(t, s.Orders)
+	finalEvent := &models.Event{
+		EventID:     "4",
+		OrderID:     thirdOrder.ID,
+		UserID:      thirdOrder.UserID,
+		OrderStatus: models.FailedStatus,
+		IsFinal:     true,
+		CreateAt:    thirdOrder.CreateAt,
+		UpdateAt:    thirdOrder.UpdateAt,
+	}
+	lateEvent := &models.Event{
+		EventID:     "5",
+		OrderID:     thirdOrder.ID,
+		UserID:      thirdOrder.UserID,
+		OrderStatus: models.PendingStatus,
+		CreateAt:    thirdOrder.CreateAt,
+		UpdateAt:    thirdOrder.CreateAt,
+	}
+	require.Nil(t, s.Events.SaveEvent(ctx, finalEvent))
+	require.Nil(t, s.Events.SaveEvent(ctx, lateEvent))
+	require.Nil(t, s.Events.SaveEvent(ctx, otherOrderEvent))
+
+	// only orders finalized before time, time of provider isn't used
+	ids, err := s.Archives.GetArchiveCandidates(ctx, thirdOrder.FinalizedAt.Add(time.Second), 10)
+	require.Nil(t, err)
+	assert.Equal(t, []string{thirdOrder.ID}, ids)
+
+	ids, err = s.Archives.GetArchiveCandidates(ctx, thirdOrder.FinalizedAt, 10)
+	require.Nil(t, err)
+	assert.Empty(t, ids)
+
+	archive, err := s.Archives.GetArchive(ctx, thirdOrder.ID)
+	require.Nil(t, err)
+	assert.Equal(t, &models.EventArchive{}, archive)
+
+	exp := &models.EventArchive{
+		OrderID:     thirdOrder.ID,
+		Key:         "events/3.ndjson.gz",
+		EventsCount: 1,
+		ArchivedAt:  time.Date(2022, 11, 10, 11, 30, 30, 0, time.UTC),
+	}
+	// late event isn't in archive and should stay
+	require.Nil(t, s.Archives.SaveArchive(ctx, exp, []string{finalEvent.EventID}))
+	assert.ErrorIs(t, s.Archives.SaveArchive(ctx, exp, nil), models.ErrAlreadyExist)
+
+	archive, err = s.Archives.GetArchive(ctx, thirdOrder.ID)
+	require.Nil(t, err)
+	archive.ArchivedAt = archive.ArchivedAt.UTC()
+	assert.Equal(t, exp, archive)
+
+	archives, err := s.Archives.GetArchives(ctx, []string{thirdOrder.ID, otherOrderEvent.OrderID})
+	require.Nil(t, err)
+	require.Len(t, archives, 1)
+	assert.Equal(t, thirdOrder.ID, archives[0].OrderID)
+
+	events, err := s.Events.GetEvents(ctx, &models.EventsFilter{OrderID: &thirdOrder.ID})
+	require.Nil(t, err)
+	assertEvents(t, []*models.Event{lateEvent}, events)
+
+	events, err = s.Events.GetEvents(ctx, &models.EventsFilter{OrderID: &otherOrderEvent.OrderID})
+	require.Nil(t, err)
+	assertEvents(t, []*models.Event{otherOrderEvent}, events)
+
+	ids, err = s.Archives.GetArchiveCandidates(ctx, thirdOrder.FinalizedAt.Add(time.Second), 10)
+	require.Nil(t, err)
+	assert.Empty(t, ids)
+}
+
+func testStats(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	saveOrders(t, s.Orders)
+	lateOrder := &models.Order{
+		ID:       "5",
+		UserID:   "user1",
+		Status:   models.OrderCreatedStatus,
+		CreateAt: time.Date(2022, 10, 10, 12, 10, 0, 0, time.UTC),
+		UpdateAt: time.Date(2022, 10, 10, 12, 10, 0, 0, time.UTC),
+	}
+	require.Nil(t, s.Orders.SaveOrder(ctx, lateOrder))
+	for _, e := range []*models.Event{createdEvent, failedEvent, otherOrderEvent} {
+		require.Nil(t, s.Events.SaveEvent(ctx, e))
+	}
+
+	sortCounts := func(counts []*models.OrdersCount) []models.OrdersCount {
+		var res []models.OrdersCount
+		for _, c := range counts {
+			res = append(res, *c)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			if !res[i].Bucket.Equal(res[j].Bucket) {
+				return res[i].Bucket.Before(res[j].Bucket)
+			}
+			return res[i].Status < res[j].Status
+		})
+		return res
+	}
+
+	counts, err := s.Stats.CountOrders(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, []models.OrdersCount{
+		{Status: models.DoneStatus, Count: 1},
+		{Status: models.OrderCreatedStatus, Count: 2},
+		{Status: models.FailedStatus, IsFinal: true, Count: 1},
+		{Status: models.PendingStatus, Count: 1},
+	}, sortCounts(counts))
+
+	counts, err = s.Stats.CountOrders(ctx, &models.StatsFilter{UserIDs: []string{"user1"}, Bucket: models.BucketHour})
+	require.Nil(t, err)
+	assert.Equal(t, []models.OrdersCount{
+		{Bucket: time.Date(2022, 10, 10, 11, 0, 0, 0, time.UTC), Status: models.OrderCreatedStatus, Count: 1},
+		{Bucket: time.Date(2022, 10, 10, 11, 0, 0, 0, time.UTC), Status: models.FailedStatus, IsFinal: true, Count: 1},
+		{Bucket: time.Date(2022, 10, 10, 12, 0, 0, 0, time.UTC), Status: models.OrderCreatedStatus, Count: 1},
+	}, sortCounts(counts))
+
+	counts, err = s.Stats.CountOrders(ctx, &models.StatsFilter{Bucket: models.BucketDay})
+	require.Nil(t, err)
+	require.NotEmpty(t, counts)
+	for _, c := range counts {
+		assert.Equal(t, time.Date(2022, 10, 10, 0, 0, 0, 0, time.UTC), c.Bucket)
+	}
+
+	reached, err := s.Stats.CountReached(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{models.OrderCreatedStatus: 2, models.FailedStatus: 1}, reached)
+
+	// created range excludes first order
+	from := secondOrder.CreateAt
+	reached, err = s.Stats.CountReached(ctx, &models.StatsFilter{From: &from})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{models.OrderCreatedStatus: 1}, reached)
+
+	expTimeInStatus := map[string]models.Percentiles{
+		models.OrderCreatedStatus: {Count: 1, P50: 10 * time.Second, P90: 10 * time.Second, P99: 10 * time.Second},
+	}
+	timeInStatus, err := s.Stats.GetStatusPercentiles(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, expTimeInStatus, timeInStatus)
+
+	// stats keep history of order after its events are archived
+	archive := &models.EventArchive{
+		OrderID:     createdEvent.OrderID,
+		Key:         "archive/1.ndjson.gz",
+		EventsCount: 2,
+		ArchivedAt:  time.Date(2022, 11, 10, 11, 30, 30, 0, time.UTC),
+	}
+	require.Nil(t, s.Archives.SaveArchive(ctx, archive, []string{createdEvent.EventID, failedEvent.EventID}))
+
+	reached, err = s.Stats.CountReached(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]int{models.OrderCreatedStatus: 2, models.FailedStatus: 1}, reached)
+
+	timeInStatus, err = s.Stats.GetStatusPercentiles(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, expTimeInStatus, timeInStatus)
+}
+
+func testPercentiles(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	createAt := time.Date(2022, 10, 10, 11, 30, 0, 0, time.UTC)
+	// orders stay in created status from 1 to 10 seconds
+	for i := 1; i <= 10; i++ {
+		id := strconv.Itoa(i)
+		order := &models.Order{ID: id, UserID: "user1", Status: models.PendingStatus, CreateAt: createAt, UpdateAt: createAt}
+		require.Nil(t, s.Orders.SaveOrder(ctx, order))
+		for _, e := range []*models.Event{
+			{EventID: id + "-created", OrderID: id, UserID: "user1", OrderStatus: models.OrderCreatedStatus, CreateAt: createAt, UpdateAt: createAt},
+			{EventID: id + "-pending", OrderID: id, UserID: "user1", OrderStatus: models.PendingStatus, CreateAt: createAt, UpdateAt: createAt.Add(time.Duration(i) * time.Second)},
+		} {
+			require.Nil(t, s.Events.SaveEvent(ctx, e))
+		}
+	}
+
+	timeInStatus, err := s.Stats.GetStatusPercentiles(ctx, &models.StatsFilter{})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]models.Percentiles{
+		models.OrderCreatedStatus: {Count: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second},
+	}, timeInStatus)
+}
+
+func saveOrders(t *testing.T, orders api.OrderStorage) {
+	for _, o := range allOrders {
+		require.Nil(t, orders.SaveOrder(context.Background(), o))
+	}
+}
+
+// drivers return time in different locations, so time is compared in UTC
+func assertOrders(t *testing.T, exp []*models.Order, res []*models.Order) {
+	t.Helper()
+	normalize := func(orders []*models.Order) []models.Order {
+		var n []models.Order
+		for _, o := range orders {
+			c := *o
+			c.CreateAt = c.CreateAt.UTC()
+			c.UpdateAt = c.UpdateAt.UTC()
+			c.FinalizedAt = c.FinalizedAt.UTC()
+			n = append(n, c)
+		}
+		return n
+	}
+	assert.Equal(t, normalize(exp), normalize(res))
+}
+
+func assertEvents(t *testing.T, exp []*models.Event, res []*models.Event) {
+	t.Helper()
+	normalize := func(events []*models.Event) []models.Event {
+		var n []models.Event
+		for _, e := range events {
+			c := *e
+			c.CreateAt = c.CreateAt.UTC()
+			c.UpdateAt = c.UpdateAt.UTC()
+			c.ReceivedAt = c.ReceivedAt.UTC()
+			n = append(n, c)
+		}
+		return n
+	}
+	assert.Equal(t, normalize(exp), normalize(res))
+}
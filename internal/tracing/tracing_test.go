@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder, provider
+}
+
+func Test_Link(t *testing.T) {
+	recorder, provider := newRecorder(t)
+	tracer := provider.Tracer("test")
+
+	// ingest span passes trace context with message
+	ctx, ingest := tracer.Start(context.Background(), "ingest")
+	carrier := Inject(ctx)
+	ingest.End()
+	require.Contains(t, carrier, "traceparent")
+
+	// delivery span is started by stream and links back to ingest
+	_, deliver := tracer.Start(context.Background(), "deliver", Link(carrier))
+	deliver.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Len(t, spans[1].Links(), 1)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].Links()[0].SpanContext.TraceID())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+	assert.NotEqual(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+}
+
+func Test_Link_Untraced(t *testing.T) {
+	recorder, provider := newRecorder(t)
+	tracer := provider.Tracer("test")
+
+	assert.Nil(t, Inject(context.Background()))
+
+	_, span := tracer.Start(context.Background(), "deliver", Link(nil))
+	span.End()
+	_, span = tracer.Start(context.Background(), "job", LinkContext(context.Background()))
+	span.End()
+
+	for _, s := range recorder.Ended() {
+		assert.Empty(t, s.Links())
+	}
+}
+
+func Test_End(t *testing.T) {
+	recorder, provider := newRecorder(t)
+	tracer := provider.Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = tracer.Start(context.Background(), "failed")
+	End(span, errors.New("connection refused"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "connection refused", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+}